@@ -0,0 +1,92 @@
+package simple_orm
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/simple_orm/model"
+	"reflect"
+	"time"
+)
+
+var (
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// timeValue 按字段类型转换时间，整型字段存储unix秒，其余存储time.Time
+func timeValue(typ reflect.Type, now time.Time) any {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return now.Unix()
+	default:
+		return now
+	}
+}
+
+// fillAutoTime 插入前写入自动时间字段：创建时间仅在零值时写入，更新时间总是写入
+func fillAutoTime(entity any, tableModel *model.TableModel, now time.Time) error {
+	if tableModel.CreateTime != nil {
+		if err := setTime(entity, tableModel.CreateTime, now, true); err != nil {
+			return err
+		}
+	}
+	if tableModel.UpdateTime != nil {
+		if err := setTime(entity, tableModel.UpdateTime, now, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withAutoTimeColumns 指定的列中缺少自动时间字段时补充，不修改调用方的切片
+func withAutoTimeColumns(columns []string, fields ...*model.Field) []string {
+	for _, field := range fields {
+		if field == nil || containsColumn(columns, field.TypName) {
+			continue
+		}
+		columns = append(columns[:len(columns):len(columns)], field.TypName)
+	}
+	return columns
+}
+
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// setTime 将时间写入实体字段，支持time.Time、*time.Time、sql.NullTime与整型unix秒
+func setTime(entity any, field *model.Field, now time.Time, onlyZero bool) error {
+	val := reflect.ValueOf(entity)
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	fd := val.FieldByName(field.TypName)
+	if !fd.CanSet() {
+		return errors.New("[auto time] field can not be set")
+	}
+	if onlyZero && !fd.IsZero() {
+		return nil
+	}
+	switch {
+	case fd.Type() == timeType:
+		fd.Set(reflect.ValueOf(now))
+	case fd.Type() == reflect.PtrTo(timeType):
+		fd.Set(reflect.ValueOf(&now))
+	case fd.Type() == nullTimeType:
+		fd.Set(reflect.ValueOf(sql.NullTime{Time: now, Valid: true}))
+	case fd.CanInt():
+		fd.SetInt(now.Unix())
+	case fd.CanUint():
+		fd.SetUint(uint64(now.Unix()))
+	default:
+		return errors.New("[auto time] unsupported field type")
+	}
+	return nil
+}
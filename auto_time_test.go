@@ -0,0 +1,191 @@
+package simple_orm
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type AutoTimeModel struct {
+	Id        int64
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+type AutoTimeTagModel struct {
+	Id       int64
+	CreateAt int64        `orm:"autoCreateTime"`
+	ModifyAt sql.NullTime `orm:"autoUpdateTime"`
+}
+
+// AutoTimeStringModel 仅字段名相同，不自动写入
+type AutoTimeStringModel struct {
+	Id        int64
+	CreatedAt string
+}
+
+type AutoTimeSoftDeleteModel struct {
+	Id        int64
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+func TestAutoTime_Insert(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	created := now.Add(-time.Hour)
+	db, err := Open("sqlite3", "file:test.db?cache=shared&mode=memory",
+		DBWithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 创建时间已赋值时不覆盖
+	entities := []any{&AutoTimeModel{Id: 1}, &AutoTimeModel{Id: 2, CreatedAt: created}}
+	q, err := NewInserter[AutoTimeModel](db).Values(entities...).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, []any{int64(1), now, &now, int64(2), created, &now}, q.Args)
+	assert.Equal(t, now, entities[0].(*AutoTimeModel).CreatedAt)
+	assert.Equal(t, created, entities[1].(*AutoTimeModel).CreatedAt)
+
+	// 标签指定字段，整型存储unix秒
+	entity := &AutoTimeTagModel{Id: 1}
+	q, err = NewInserter[AutoTimeTagModel](db).Values(entity).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, now.Unix(), entity.CreateAt)
+	assert.Equal(t, sql.NullTime{Time: now, Valid: true}, entity.ModifyAt)
+
+	// 指定的列中缺少自动时间字段时补充
+	q, err = NewInserter[AutoTimeModel](db).Columns("Id").Values(&AutoTimeModel{Id: 3}).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "INSERT INTO `auto_time_model`(`id`,`created_at`,`updated_at`) VALUES(?,?,?);",
+		Args: []any{int64(3), now, &now},
+	}, q)
+
+	q, err = NewInserter[AutoTimeStringModel](db).Values(&AutoTimeStringModel{Id: 1, CreatedAt: "2022"}).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, []any{int64(1), "2022"}, q.Args)
+}
+
+func TestAutoTime_Upsert(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	db, err := Open("sqlite3", "file:test.db?cache=shared&mode=memory",
+		DBWithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name      string
+		q         QueryBuilder
+		wantQuery *Query
+	}{
+		{
+			// 插入的列包含更新时间，使用插入的值
+			name: "use insert value",
+			q: NewInserter[AutoTimeModel](db).Values(&AutoTimeModel{Id: 1}).
+				OnDuplicateKey().Update(NewColumn("Id")),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `auto_time_model`(`id`,`created_at`,`updated_at`) VALUES(?,?,?) " +
+					"ON DUPLICATE KEY UPDATE `id`=VALUES(`id`),`updated_at`=VALUES(`updated_at`);",
+				Args: []any{int64(1), now, &now},
+			},
+		},
+		{
+			// 指定的列中缺少自动时间字段时补充
+			name: "partial columns",
+			q: NewInserter[AutoTimeModel](db).Values(&AutoTimeModel{Id: 1}).Columns("Id").
+				OnDuplicateKey().Update(NewColumn("Id")),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `auto_time_model`(`id`,`created_at`,`updated_at`) VALUES(?,?,?) " +
+					"ON DUPLICATE KEY UPDATE `id`=VALUES(`id`),`updated_at`=VALUES(`updated_at`);",
+				Args: []any{int64(1), now, &now},
+			},
+		},
+		{
+			// 用户指定了更新时间
+			name: "user assign",
+			q: NewInserter[AutoTimeModel](db).Values(&AutoTimeModel{Id: 1}).Columns("Id").
+				OnDuplicateKey().Update(Assign("UpdatedAt", "2020-01-01")),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `auto_time_model`(`id`,`created_at`,`updated_at`) VALUES(?,?,?) " +
+					"ON DUPLICATE KEY UPDATE `updated_at`=?;",
+				Args: []any{int64(1), now, &now, "2020-01-01"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.q.Build()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestAutoTime_Update(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	created := now.Add(-time.Hour)
+	db, err := Open("sqlite3", "file:test.db?cache=shared&mode=memory",
+		DBWithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name      string
+		q         QueryBuilder
+		wantQuery *Query
+	}{
+		{
+			name: "all columns",
+			q: NewUpdater[AutoTimeModel](db).Update(&AutoTimeModel{Id: 1, CreatedAt: created}).
+				Where(NewColumn("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `auto_time_model` SET `id`=?,`created_at`=?,`updated_at`=? WHERE `id` = ?;",
+				Args: []any{int64(1), created, &now, 1},
+			},
+		},
+		{
+			// 指定的列中缺少更新时间时补充，不补充创建时间
+			name: "partial columns",
+			q: NewUpdater[AutoTimeModel](db).Update(&AutoTimeModel{Id: 1}).Columns("Id").
+				Where(NewColumn("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `auto_time_model` SET `id`=?,`updated_at`=? WHERE `id` = ?;",
+				Args: []any{int64(1), &now, 1},
+			},
+		},
+		{
+			name: "tag",
+			q: NewUpdater[AutoTimeTagModel](db).Update(&AutoTimeTagModel{Id: 1}).Columns("Id").
+				Where(NewColumn("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `auto_time_tag_model` SET `id`=?,`modify_at`=? WHERE `id` = ?;",
+				Args: []any{int64(1), sql.NullTime{Time: now, Valid: true}, 1},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.q.Build()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestAutoTime_SoftDelete(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	db, err := Open("sqlite3", "file:test.db?cache=shared&mode=memory",
+		DBWithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := NewDeleter[AutoTimeSoftDeleteModel](db).Where(NewColumn("Id").EQ(1)).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, &Query{
+		SQL: "UPDATE `auto_time_soft_delete_model` SET `deleted_at` = ?,`updated_at` = ? " +
+			"WHERE (`id` = ?) AND (`deleted_at` IS NULL);",
+		Args: []any{now, now, 1},
+	}, q)
+}
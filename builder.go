@@ -1,6 +1,7 @@
 package simple_orm
 
 import (
+	"errors"
 	"github.com/simple_orm/model"
 	"strings"
 )
@@ -9,4 +10,137 @@ type Builder struct {
 	sb          strings.Builder
 	tableModels *model.TableModel
 	args        []any
+	argCols     []string // 与args一一对应的列名，参数没有对应列时为空字符串
+	tenantVal   any      // ctx中的租户，见DBWithTenant
+	tenantBound bool
+}
+
+// addArg 添加参数，col是参数对应的列名
+func (b *Builder) addArg(col string, val any) {
+	b.args = append(b.args, val)
+	b.argCols = append(b.argCols, col)
+}
+
+// argColumns 参数对应的列名，供中间件做脱敏等处理
+func (b *Builder) argColumns() []string {
+	return b.argCols
+}
+
+// buildWhere 多个条件以AND连接
+func (b *Builder) buildWhere(where []*Predicate) error {
+	if len(where) == 0 {
+		return nil
+	}
+	b.sb.WriteString(" WHERE ")
+	p := where[0]
+	for i := 1; i < len(where); i++ {
+		p = p.And(where[i])
+	}
+	return b.buildExpression(p)
+}
+
+// 递归解析表达式
+// (`Age` > 13) AND (`Age` < 24)
+func (b *Builder) buildExpression(e Expression) error {
+	switch expr := e.(type) {
+	case *Aggregate:
+		field, ok := b.tableModels.Col2Field[expr.name]
+		if !ok {
+			return errors.New("illegal field")
+		}
+		b.sb.WriteString(string(expr.aggregateFunction))
+		b.sb.WriteString("(`")
+		b.sb.WriteString(field.ColumnName)
+		b.sb.WriteString("`)")
+	case *Column: // 列， eg：`Age`
+		if _, ok := b.tableModels.Col2Field[expr.name]; !ok {
+			return errors.New("illegal field")
+		}
+		b.sb.WriteByte('`')
+		b.sb.WriteString(b.tableModels.Col2Field[expr.name].ColumnName)
+		b.sb.WriteByte('`')
+	case *rowValue: // 行值，eg：(`a`,`b`)
+		b.sb.WriteByte('(')
+		for i, e := range expr.exprs {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			if err := b.buildExpression(e); err != nil {
+				return err
+			}
+		}
+		b.sb.WriteByte(')')
+	case *Value: // 值，eg： 13
+		b.sb.WriteByte('?')
+		b.addArg("", expr.val)
+	case *Predicate: // 表达式
+		// 左侧表达式
+		leftStart := len(b.args)
+		_, lp := expr.left.(*Predicate)
+		if lp {
+			b.sb.WriteByte('(')
+		}
+		if err := b.buildExpression(expr.left); err != nil {
+			return err
+		}
+		if lp {
+			b.sb.WriteByte(')')
+		}
+		// 链接符
+		b.sb.WriteByte(' ')
+		b.sb.WriteString(string(expr.op))
+		// IS NULL 等没有右侧表达式
+		if expr.right == nil {
+			break
+		}
+		b.sb.WriteByte(' ')
+		// 右侧表达式
+		rightStart := len(b.args)
+		_, rp := expr.right.(*Predicate)
+		if rp {
+			b.sb.WriteByte('(')
+		}
+		if err := b.buildExpression(expr.right); err != nil {
+			return err
+		}
+		if rp {
+			b.sb.WriteByte(')')
+		}
+		// 列与值比较时记录参数对应的列，值可能在任意一侧
+		b.bindArgCols(expr.left, expr.right, rightStart)
+		b.bindArgCols(expr.right, expr.left, leftStart)
+	}
+	return nil
+}
+
+// bindArgCols 记录从start开始的参数对应的列：列与值、列与值列表、行值与行值逐项对应
+func (b *Builder) bindArgCols(cols Expression, vals Expression, start int) {
+	switch c := cols.(type) {
+	case *Column:
+		switch v := vals.(type) {
+		case *Value:
+			b.argCols[start] = c.name
+		case *rowValue:
+			for _, e := range v.exprs {
+				if _, ok := e.(*Value); ok {
+					b.argCols[start] = c.name
+					start++
+				}
+			}
+		}
+	case *rowValue:
+		v, ok := vals.(*rowValue)
+		if !ok {
+			return
+		}
+		for i, e := range v.exprs {
+			if _, ok = e.(*Value); !ok {
+				continue
+			}
+			if i < len(c.exprs) {
+				b.bindArgCols(c.exprs[i], e, start)
+			}
+			start++
+		}
+	}
 }
@@ -56,3 +56,17 @@ func (c *Column) GT(val any) *Predicate {
 		op:    model.OpGT,
 	}
 }
+
+func (c *Column) IsNull() *Predicate {
+	return &Predicate{
+		left: c,
+		op:   model.OpIsNull,
+	}
+}
+
+func (c *Column) IsNotNull() *Predicate {
+	return &Predicate{
+		left: c,
+		op:   model.OpIsNotNull,
+	}
+}
@@ -2,105 +2,207 @@ package simple_orm
 
 import (
 	"context"
-	"errors"
+	"reflect"
 )
 
-func get[T any](ctx context.Context, core core, session session, qc *QueryContext) (*T, error) {
+func get[T any](ctx context.Context, core core, session Session, typ string, builder QueryBuilder) (*T, error) {
+	qc, err := newQueryContext[T](ctx, core, session, typ, builder)
+	if err != nil {
+		return nil, err
+	}
+	qc.ResultType = reflect.TypeOf((*T)(nil))
 	var handler HandleFunc = func(ctx context.Context, qc *QueryContext) *QueryResult {
 		return getHandler[T](ctx, session, core, qc)
 	}
-	middlewares := core.middleWares
-	for i := len(middlewares) - 1; i >= 0; i-- {
-		handler = middlewares[i](handler)
-	}
-	queryResult := handler(ctx, qc)
+	queryResult := chain(handler, core.middleWares)(ctx, qc)
 	if queryResult.Err != nil {
 		return nil, queryResult.Err
 	}
 	return queryResult.Result.(*T), nil
 }
 
-func getMul[T any](ctx context.Context, core core, session session, qc *QueryContext) ([]*T, error) {
+func getMul[T any](ctx context.Context, core core, session Session, typ string, builder QueryBuilder) ([]*T, error) {
+	qc, err := newQueryContext[T](ctx, core, session, typ, builder)
+	if err != nil {
+		return nil, err
+	}
+	qc.Multi = true
+	qc.ResultType = reflect.TypeOf([]*T(nil))
 	var handler HandleFunc = func(ctx context.Context, qc *QueryContext) *QueryResult {
 		return getMulHandler[T](ctx, core, session, qc)
 	}
-	middlewares := core.middleWares
-	for i := len(middlewares) - 1; i >= 0; i-- {
-		handler = middlewares[i](handler)
-	}
-	queryResult := handler(ctx, qc)
+	queryResult := chain(handler, core.middleWares)(ctx, qc)
 	if queryResult.Err != nil {
 		return nil, queryResult.Err
 	}
 	return queryResult.Result.([]*T), nil
 }
 
-func getHandler[T any](ctx context.Context, session session, core core, qc *QueryContext) *QueryResult {
-	query, err := qc.Builder.Build()
+func getHandler[T any](ctx context.Context, session Session, core core, qc *QueryContext) *QueryResult {
+	scan, err := newRowScanner[T](core)
 	if err != nil {
 		return &QueryResult{
 			Err: err,
 		}
 	}
-	rows, err := session.queryContext(ctx, query.SQL, query.Args...)
+	rows, err := session.queryContext(ctx, qc.Query.SQL, qc.Query.Args...)
 	if err != nil {
 		return &QueryResult{
-			Err: err,
+			Err: wrapError(core.dialect, qc.Query.SQL, err),
 		}
 	}
+	defer func() { _ = rows.Close() }()
 	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return &QueryResult{
+				Err: wrapError(core.dialect, qc.Query.SQL, err),
+			}
+		}
 		return &QueryResult{
-			Err: errors.New("not data"),
+			Err: newNoRowsError(qc.Query.SQL),
 		}
 	}
 
-	tp := new(T)
-	tableModel, err := core.r.Get(tp)
+	tp, err := scan(rows)
 	if err != nil {
 		return &QueryResult{
-			Err: err,
+			Err: wrapError(core.dialect, qc.Query.SQL, err),
+		}
+	}
+	if hook, ok := any(tp).(AfterFindHook); ok {
+		if err = hook.AfterFind(ctx, session); err != nil {
+			return &QueryResult{
+				Err: abortByHook(session, err),
+			}
 		}
 	}
-	val := core.creator(tp, tableModel)
-	err = val.SetColumns(rows)
 	return &QueryResult{
 		Result: tp,
-		Err:    err,
 	}
 }
 
-func getMulHandler[T any](ctx context.Context, core core, session session, qc *QueryContext) *QueryResult {
-	query, err := qc.Builder.Build()
+func getMulHandler[T any](ctx context.Context, core core, session Session, qc *QueryContext) *QueryResult {
+	scan, err := newRowScanner[T](core)
 	if err != nil {
 		return &QueryResult{
 			Err: err,
 		}
 	}
-	rows, err := session.queryContext(ctx, query.SQL, query.Args...)
+	rows, err := session.queryContext(ctx, qc.Query.SQL, qc.Query.Args...)
 	if err != nil {
 		return &QueryResult{
-			Err: err,
+			Err: wrapError(core.dialect, qc.Query.SQL, err),
 		}
 	}
+	defer func() { _ = rows.Close() }()
 	tpArr := make([]*T, 0)
 	for rows.Next() {
-		tp := new(T)
-		tableModel, err := core.r.Get(tp)
+		tp, err := scan(rows)
 		if err != nil {
 			return &QueryResult{
-				Err: err,
+				Err: wrapError(core.dialect, qc.Query.SQL, err),
 			}
 		}
-		val := core.creator(tp, tableModel)
-		err = val.SetColumns(rows)
-		if err != nil {
-			return &QueryResult{
-				Err: err,
+		if hook, ok := any(tp).(AfterFindHook); ok {
+			if err = hook.AfterFind(ctx, session); err != nil {
+				return &QueryResult{
+					Err: abortByHook(session, err),
+				}
 			}
 		}
 		tpArr = append(tpArr, tp)
 	}
+	// 读取过程中出现的错误，如连接中断
+	if err = rows.Err(); err != nil {
+		return &QueryResult{
+			Err: wrapError(core.dialect, qc.Query.SQL, err),
+		}
+	}
 	return &QueryResult{
 		Result: tpArr,
 	}
 }
+
+func iter[T any](ctx context.Context, core core, session Session, typ string, builder QueryBuilder) (*Iterator[T], error) {
+	qc, err := newQueryContext[T](ctx, core, session, typ, builder)
+	if err != nil {
+		return nil, err
+	}
+	qc.Stream = true
+	qc.ResultType = reflect.TypeOf((*Iterator[T])(nil))
+	var handler HandleFunc = func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return iterHandler[T](ctx, core, session, qc)
+	}
+	queryResult := chain(handler, core.middleWares)(ctx, qc)
+	if queryResult.Err != nil {
+		return nil, queryResult.Err
+	}
+	return queryResult.Result.(*Iterator[T]), nil
+}
+
+func iterHandler[T any](ctx context.Context, core core, session Session, qc *QueryContext) *QueryResult {
+	scan, err := newRowScanner[T](core)
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	rows, err := session.queryContext(ctx, qc.Query.SQL, qc.Query.Args...)
+	if err != nil {
+		return &QueryResult{
+			Err: wrapError(core.dialect, qc.Query.SQL, err),
+		}
+	}
+	return &QueryResult{
+		Result: &Iterator[T]{
+			ctx:     ctx,
+			dialect: core.dialect,
+			query:   qc.Query.SQL,
+			session: session,
+			rows:    rows,
+			scan:    scan,
+		},
+	}
+}
+
+// count 执行COUNT查询，T用于获取模型
+func count[T any](ctx context.Context, core core, session Session, builder QueryBuilder) (int64, error) {
+	qc, err := newQueryContext[T](ctx, core, session, QueryTypeSelect, builder)
+	if err != nil {
+		return 0, err
+	}
+	qc.Count = true
+	qc.ResultType = reflect.TypeOf((*int64)(nil))
+	var handler HandleFunc = func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return countHandler(ctx, core, session, qc)
+	}
+	queryResult := chain(handler, core.middleWares)(ctx, qc)
+	if queryResult.Err != nil {
+		return 0, queryResult.Err
+	}
+	return *queryResult.Result.(*int64), nil
+}
+
+func countHandler(ctx context.Context, core core, session Session, qc *QueryContext) *QueryResult {
+	rows, err := session.queryContext(ctx, qc.Query.SQL, qc.Query.Args...)
+	if err != nil {
+		return &QueryResult{
+			Err: wrapError(core.dialect, qc.Query.SQL, err),
+		}
+	}
+	defer func() { _ = rows.Close() }()
+	total := new(int64)
+	if rows.Next() {
+		err = rows.Scan(total)
+	} else {
+		err = rows.Err()
+	}
+	if err != nil {
+		return &QueryResult{
+			Err: wrapError(core.dialect, qc.Query.SQL, err),
+		}
+	}
+	return &QueryResult{
+		Result: total,
+	}
+}
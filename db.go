@@ -3,18 +3,21 @@ package simple_orm
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"github.com/hashicorp/go-multierror"
 	"github.com/simple_orm/model"
 	"github.com/simple_orm/sharding"
 	"github.com/simple_orm/valuer"
 	"reflect"
+	"time"
 )
 
 type DBOption func(db *DB)
 
 type DB struct {
-	core          // 元数据信息
-	store *sql.DB // 对应具体数据库的存储
+	core                                // 元数据信息
+	store            *sql.DB            // 对应具体数据库的存储
+	namedMiddleWares []*namedMiddleWare // 注册的中间件，OpenDB时排序后放入core
 }
 
 type TxKey struct {
@@ -37,11 +40,17 @@ func OpenDB(store *sql.DB, opts ...DBOption) (*DB, error) {
 			},
 			creator: valuer.NewUnsafeValue,
 			dialect: mySQLDialect,
+			clock:   time.Now,
 		},
 	}
 	for _, opt := range opts {
 		opt(db)
 	}
+	middleWares, err := sortMiddleWares(db.namedMiddleWares)
+	if err != nil {
+		return nil, err
+	}
+	db.core.middleWares = middleWares
 	return db, nil
 }
 
@@ -71,9 +80,43 @@ func DBWithDialect(dialect Dialect) DBOption {
 	}
 }
 
+// DBWithClock 配置自动写入时间字段的时间源，默认time.Now，测试中可以固定时间
+func DBWithClock(clock func() time.Time) DBOption {
+	return func(db *DB) {
+		db.clock = clock
+	}
+}
+
+// DBWithTenant 开启租户隔离，column是租户字段名。查询、删除、更新追加租户条件，插入时写入租户；
+// ctx中没有租户时返回ErrNoTenant，没有该字段的模型视为全局表
+func DBWithTenant(column string, extract TenantFunc) DBOption {
+	return func(db *DB) {
+		db.tenant = &tenantRule{
+			column:  column,
+			extract: extract,
+		}
+	}
+}
+
 func DBWithMiddleWare(middleWares ...MiddleWare) DBOption {
 	return func(db *DB) {
-		db.middleWares = append(db.middleWares, middleWares...)
+		for _, m := range middleWares {
+			db.namedMiddleWares = append(db.namedMiddleWares, &namedMiddleWare{middleWare: m})
+		}
+	}
+}
+
+// DBWithNamedMiddleWare 注册带名字的中间件，可以通过MiddleWareBefore/MiddleWareAfter指定与其他中间件的先后顺序
+func DBWithNamedMiddleWare(name string, middleWare MiddleWare, opts ...MiddleWareOption) DBOption {
+	return func(db *DB) {
+		m := &namedMiddleWare{
+			name:       name,
+			middleWare: middleWare,
+		}
+		for _, opt := range opts {
+			opt(m)
+		}
+		db.namedMiddleWares = append(db.namedMiddleWares, m)
 	}
 }
 
@@ -84,8 +127,9 @@ func (db *DB) beginTx(ctx context.Context, opts *sql.TxOptions) (*TX, error) {
 		return nil, err
 	}
 	return &TX{
-		tx: tx,
-		db: db,
+		tx:  tx,
+		db:  db,
+		ctx: ctx,
 	}, nil
 }
 
@@ -101,7 +145,7 @@ func (db *DB) beginTxIfNotExists(ctx context.Context, opts *sql.TxOptions) (cont
 		return ctx, nil, err
 	}
 	ctx = context.WithValue(ctx, TxKey{}, tx)
-	return ctx, &TX{tx: tx, db: db}, nil
+	return ctx, &TX{tx: tx, db: db, ctx: ctx}, nil
 }
 
 // 事务闭包：当执行事务出错或执行中发生panic需要回滚
@@ -115,9 +159,9 @@ func (db *DB) doTx(ctx context.Context, task func(ctx2 context.Context, tx *TX)
 		if !panicked && err == nil {
 			return
 		}
-		// 回滚中出现问题则将error追加
+		// 回滚中出现问题则将error追加，钩子出错时事务可能已经回滚
 		txErr := tx.Rollback()
-		if txErr != nil {
+		if txErr != nil && !errors.Is(txErr, sql.ErrTxDone) {
 			err = multierror.Append(err, txErr)
 		}
 	}()
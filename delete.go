@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"github.com/simple_orm/master_slave"
 )
 
 type Delete[T any] struct {
@@ -12,10 +13,13 @@ type Delete[T any] struct {
 	values  []any
 	table   string
 	where   []*Predicate
-	session session
+	session Session
+
+	unscoped bool // 软删除模型也执行物理删除
+	restore  bool // 恢复软删除的数据，见Restorer
 }
 
-func NewDeleter[T any](session session) *Delete[T] {
+func NewDeleter[T any](session Session) *Delete[T] {
 	return &Delete[T]{
 		core:    session.getCore(),
 		session: session,
@@ -33,6 +37,19 @@ func (d *Delete[T]) Where(where ...*Predicate) *Delete[T] {
 	return d
 }
 
+// Unscoped 软删除模型执行物理删除
+func (d *Delete[T]) Unscoped() *Delete[T] {
+	d.unscoped = true
+	return d
+}
+
+// Use 添加仅对本次查询生效的中间件，在DB级别的中间件之后执行
+func (d *Delete[T]) Use(middleWares ...MiddleWare) *Delete[T] {
+	// 限制容量，避免append时修改DB共享的底层数组
+	d.middleWares = append(d.middleWares[:len(d.middleWares):len(d.middleWares)], middleWares...)
+	return d
+}
+
 func (d *Delete[T]) Build() (*Query, error) {
 	var (
 		t   T
@@ -42,29 +59,78 @@ func (d *Delete[T]) Build() (*Query, error) {
 	if err != nil {
 		return nil, err
 	}
+	if d.restore {
+		if d.tableModels.SoftDelete == nil {
+			return nil, errors.New("[restore] model has no soft delete field")
+		}
+		return d.buildUpdate()
+	}
+	if d.softDelete() {
+		return d.buildUpdate()
+	}
+	where, err := d.tenantWhere(d.tenant, d.where)
+	if err != nil {
+		return nil, err
+	}
 	d.sb.WriteString("DELETE FROM ")
-	// table name
-	if d.table == "" {
-		d.sb.WriteByte('`')
-		d.sb.WriteString(d.tableModels.TableName)
-		d.sb.WriteByte('`')
-	} else {
-		d.sb.WriteString(d.table)
+	d.writeTable()
+	err = d.buildWhere(where)
+	if err != nil {
+		return nil, err
 	}
+	d.sb.WriteString(";")
+	return &Query{
+		SQL:  d.sb.String(),
+		Args: d.args,
+	}, nil
+}
 
-	// where
-	if len(d.where) > 0 {
-		d.sb.WriteString(" WHERE ")
-		p := d.where[0]
-		for i := 1; i < len(d.where); i++ {
-			p = p.And(d.where[i])
-		}
-		err = d.buildExpression(p)
-		if err != nil {
-			return nil, err
-		}
-	}
+// softDelete 是否以UPDATE删除时间代替物理删除，需要先Build得到tableModels
+func (d *Delete[T]) softDelete() bool {
+	return d.tableModels.SoftDelete != nil && !d.unscoped
+}
 
+// buildUpdate 软删除写入删除时间，恢复时置为NULL，条件中排除不需要处理的行
+func (d *Delete[T]) buildUpdate() (*Query, error) {
+	field := d.tableModels.SoftDelete
+	d.sb.WriteString("UPDATE ")
+	d.writeTable()
+	d.sb.WriteString(" SET `")
+	d.sb.WriteString(field.ColumnName)
+	d.sb.WriteString("` = ")
+	where := make([]*Predicate, 0, len(d.where)+1)
+	where = append(where, d.where...)
+	now := d.clock()
+	if d.restore {
+		d.sb.WriteString("NULL")
+		where = append(where, NewColumn(field.TypName).IsNotNull())
+	} else {
+		d.sb.WriteByte('?')
+		d.addArg(field.TypName, timeValue(field.Typ, now))
+		where = append(where, NewColumn(field.TypName).IsNull())
+	}
+	// 同时刷新更新时间
+	if updateTime := d.tableModels.UpdateTime; updateTime != nil {
+		d.sb.WriteString(",`")
+		d.sb.WriteString(updateTime.ColumnName)
+		d.sb.WriteString("` = ?")
+		d.addArg(updateTime.TypName, timeValue(updateTime.Typ, now))
+	}
+	// 删除与恢复都使持有旧版本号的实体失效
+	if version := d.tableModels.Version; version != nil {
+		d.sb.WriteString(",`")
+		d.sb.WriteString(version.ColumnName)
+		d.sb.WriteString("` = `")
+		d.sb.WriteString(version.ColumnName)
+		d.sb.WriteString("` + 1")
+	}
+	where, err := d.tenantWhere(d.tenant, where)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.buildWhere(where); err != nil {
+		return nil, err
+	}
 	d.sb.WriteString(";")
 	return &Query{
 		SQL:  d.sb.String(),
@@ -72,89 +138,53 @@ func (d *Delete[T]) Build() (*Query, error) {
 	}, nil
 }
 
-// 递归解析表达式
-// (`Age` > 13) AND (`Age` < 24)
-func (d *Delete[T]) buildExpression(e Expression) error {
-	switch expr := e.(type) {
-	case *Aggregate:
-		field, ok := d.tableModels.Col2Field[expr.name]
-		if !ok {
-			return errors.New("illegal field")
-		}
-		d.sb.WriteString(string(expr.aggregateFunction))
-		d.sb.WriteString("(`")
-		d.sb.WriteString(field.ColumnName)
-		d.sb.WriteString("`)")
-	case *Column: // 列， eg：`Age`
-		if _, ok := d.tableModels.Col2Field[expr.name]; !ok {
-			return errors.New("illegal field")
-		}
+func (d *Delete[T]) writeTable() {
+	if d.table == "" {
 		d.sb.WriteByte('`')
-		d.sb.WriteString(d.tableModels.Col2Field[expr.name].ColumnName)
+		d.sb.WriteString(d.tableModels.TableName)
 		d.sb.WriteByte('`')
-	case *Value: // 值，eg： 13
-		d.sb.WriteByte('?')
-		d.args = append(d.args, expr.val)
-	case *Predicate: // 表达式
-		// 左侧表达式
-		_, lp := expr.left.(*Predicate)
-		if lp {
-			d.sb.WriteByte('(')
-		}
-		if err := d.buildExpression(expr.left); err != nil {
-			return err
-		}
-		if lp {
-			d.sb.WriteByte(')')
-		}
-		// 链接符
-		d.sb.WriteByte(' ')
-		d.sb.WriteString(string(expr.op))
-		d.sb.WriteByte(' ')
-		// 右侧表达式
-		_, rp := expr.right.(*Predicate)
-		if rp {
-			d.sb.WriteByte('(')
-		}
-		if err := d.buildExpression(expr.right); err != nil {
-			return err
-		}
-		if rp {
-			d.sb.WriteByte(')')
-		}
+	} else {
+		d.sb.WriteString(d.table)
 	}
-	return nil
 }
 
-func (d *Delete[T]) execHandler(ctx context.Context, qc *QueryContext) *QueryResult {
-	query, err := d.Build()
-	if err != nil {
-		return &QueryResult{
-			Err: err,
-		}
+// whereColumns WHERE中引用的列，没有WHERE时为nil
+func (d *Delete[T]) whereColumns() []string {
+	if len(d.where) == 0 {
+		return nil
 	}
-	result, err := d.session.execContext(ctx, query.SQL, query.Args...)
+	return columnsOf(d.where...)
+}
+
+func (d *Delete[T]) execHandler(ctx context.Context, qc *QueryContext) *QueryResult {
+	result, err := d.session.execContext(ctx, qc.Query.SQL, qc.Query.Args...)
 	if err != nil {
 		return &QueryResult{
-			Err: err,
+			Err: wrapError(d.dialect, qc.Query.SQL, err),
 		}
 	}
-	return &QueryResult{
-		Result: result,
-		Err:    err,
-	}
+	return newExecResult(result)
 }
 
 func (d *Delete[T]) Exec(ctx context.Context) (sql.Result, error) {
-	var handler HandleFunc = d.execHandler
-	middlewares := d.middleWares
-	for idx := len(middlewares) - 1; idx >= 0; idx-- {
-		handler = middlewares[idx](handler)
+	if hook, ok := any(new(T)).(BeforeDeleteHook); ok && !d.restore {
+		if err := hook.BeforeDelete(ctx, d.session); err != nil {
+			return nil, abortByHook(d.session, err)
+		}
+	}
+	qc, err := newQueryContext[T](ctx, d.core, d.session, QueryTypeDelete, d)
+	if err != nil {
+		return nil, err
+	}
+	// 软删除与恢复实际执行的是UPDATE
+	if d.restore || d.softDelete() {
+		qc.Type = QueryTypeUpdate
 	}
-	qc := &QueryContext{}
-	queryResult := handler(ctx, qc)
+	queryResult := chain(d.execHandler, d.middleWares)(ctx, qc)
 	if queryResult.Err != nil {
 		return nil, queryResult.Err
 	}
+	// 写入成功后，同一ctx中的读请求在粘滞窗口内走主库
+	master_slave.MarkWrite(ctx)
 	return queryResult.Result.(sql.Result), nil
 }
@@ -2,27 +2,77 @@ package simple_orm
 
 import (
 	"errors"
+	"github.com/simple_orm/valuer"
 )
 
 var (
 	mySQLDialect = &MySQLDialect{}
 )
 
+// Dialect 包外实现时可以嵌入BaseDialect，获得Lock与ClassifyError的默认实现，只需实现Name与Upsert
 type Dialect interface {
+	// Name 方言名称，如mysql
+	Name() string
 	// Upsert 不同的数据库实现不同的Upsert
 	Upsert(builder *Builder, upsert *UpsertKey) error
+	// Lock 行锁子句，wait为空时表示等待
+	Lock(builder *Builder, mode LockMode, wait LockWait) error
+	// ClassifyError 将驱动错误分类为ErrDuplicateKey等，无法分类时返回nil
+	ClassifyError(err error) error
+}
+
+// BaseDialect Lock与ClassifyError的默认实现，ClassifyError只识别与驱动无关的错误
+type BaseDialect struct {
+	standardSQL
 }
 
 type standardSQL struct {
 }
 
+// ClassifyError 只识别与驱动无关的错误，各方言在此之前识别自己驱动的错误
+func (s standardSQL) ClassifyError(err error) error {
+	if errors.Is(err, valuer.ColumnsNotExists) {
+		return ErrColumnNotFound
+	}
+	return nil
+}
+
+// Lock MySQL 8.0 与 PostgreSQL 均支持 FOR UPDATE/FOR SHARE [NOWAIT|SKIP LOCKED]
+func (s standardSQL) Lock(builder *Builder, mode LockMode, wait LockWait) error {
+	builder.sb.WriteByte(' ')
+	builder.sb.WriteString(string(mode))
+	if wait != "" {
+		builder.sb.WriteByte(' ')
+		builder.sb.WriteString(string(wait))
+	}
+	return nil
+}
+
 type MySQLDialect struct {
 	standardSQL
 }
 
+func (m *MySQLDialect) Name() string {
+	return "mysql"
+}
+
+func (m *MySQLDialect) ClassifyError(err error) error {
+	if kind := classifyMySQLError(err); kind != nil {
+		return kind
+	}
+	return m.standardSQL.ClassifyError(err)
+}
+
 func (m *MySQLDialect) Upsert(builder *Builder, upsert *UpsertKey) error {
+	// ON DUPLICATE KEY UPDATE无法限定更新的行，如租户表冲突时会更新其他租户的数据
+	if len(upsert.where) > 0 {
+		return errors.New("[upsert] mysql does not support conditional upsert")
+	}
 	builder.sb.WriteString(" ON DUPLICATE KEY UPDATE ")
-	for _, assign := range upsert.assigns {
+	for idx, assign := range upsert.assigns {
+		if idx > 0 {
+			builder.sb.WriteString(",")
+		}
 		switch e := assign.(type) {
 		case *Column:
 			columnName := e.name
@@ -46,8 +96,60 @@ func (m *MySQLDialect) Upsert(builder *Builder, upsert *UpsertKey) error {
 			builder.sb.WriteString(field.ColumnName)
 			builder.sb.WriteString("`")
 			builder.sb.WriteString("=?")
-			builder.args = append(builder.args, e.Val)
+			builder.addArg(columnName, e.Val)
 		}
 	}
 	return nil
 }
+
+type SQLiteDialect struct {
+	standardSQL
+}
+
+func (s *SQLiteDialect) Name() string {
+	return "sqlite"
+}
+
+// Upsert SQLite 3.35 起最后一个ON CONFLICT子句可以省略冲突列
+func (s *SQLiteDialect) Upsert(builder *Builder, upsert *UpsertKey) error {
+	return onConflict(builder, upsert)
+}
+
+// Lock SQLite以库为单位加锁，不支持行锁
+func (s *SQLiteDialect) Lock(builder *Builder, mode LockMode, wait LockWait) error {
+	return errors.New("[lock] sqlite does not support row lock")
+}
+
+func (s *SQLiteDialect) ClassifyError(err error) error {
+	if kind := classifySQLiteError(err); kind != nil {
+		return kind
+	}
+	return s.standardSQL.ClassifyError(err)
+}
+
+// onConflict ON CONFLICT DO UPDATE SET `b`=excluded.`b`,`c`=? WHERE `d` = ?
+func onConflict(builder *Builder, upsert *UpsertKey) error {
+	builder.sb.WriteString(" ON CONFLICT DO UPDATE SET ")
+	for idx, assign := range upsert.assigns {
+		if idx > 0 {
+			builder.sb.WriteString(",")
+		}
+		switch e := assign.(type) {
+		case *Column:
+			field, ok := builder.tableModels.Col2Field[e.name]
+			if !ok {
+				return errors.New("column name not exists")
+			}
+			builder.sb.WriteString("`" + field.ColumnName + "`=excluded.`" + field.ColumnName + "`")
+		case *Assignment:
+			field, ok := builder.tableModels.Col2Field[e.ColumnName]
+			if !ok {
+				return errors.New("column name not exists")
+			}
+			builder.sb.WriteString("`" + field.ColumnName + "`=?")
+			builder.addArg(e.ColumnName, e.Val)
+		}
+	}
+	// 未限定的列名指冲突的已有行
+	return builder.buildWhere(upsert.where)
+}
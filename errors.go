@@ -0,0 +1,126 @@
+package simple_orm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"strings"
+)
+
+// 分类后的错误，使用errors.Is判断，eg：errors.Is(err, ErrDuplicateKey)
+var (
+	ErrNoRows         = errors.New("[orm] no rows")
+	ErrDuplicateKey   = errors.New("[orm] duplicate key")
+	ErrForeignKey     = errors.New("[orm] foreign key violation")
+	ErrDeadlock       = errors.New("[orm] deadlock")
+	ErrLockTimeout    = errors.New("[orm] lock wait timeout")
+	ErrColumnNotFound = errors.New("[orm] column not found")
+)
+
+// QueryError 执行SQL出错，Kind是分类后的错误，无法分类时为nil，Err是驱动等返回的原始错误，
+// errors.Is同时匹配两者，errors.As可以取出驱动的错误类型
+type QueryError struct {
+	SQL  string
+	Kind error
+	Err  error
+}
+
+func (e *QueryError) Error() string {
+	if e.Kind == nil {
+		return fmt.Sprintf("%v, sql: %s", e.Err, e.SQL)
+	}
+	return fmt.Sprintf("%v: %v, sql: %s", e.Kind, e.Err, e.SQL)
+}
+
+func (e *QueryError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError 按方言分类错误并附带SQL，无法分类的错误Kind为nil
+func wrapError(dialect Dialect, query string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var qe *QueryError
+	if errors.As(err, &qe) {
+		return err
+	}
+	return &QueryError{
+		SQL:  query,
+		Kind: dialect.ClassifyError(err),
+		Err:  err,
+	}
+}
+
+// newNoRowsError 查询没有返回数据
+func newNoRowsError(query string) error {
+	return &QueryError{
+		SQL:  query,
+		Kind: ErrNoRows,
+		Err:  sql.ErrNoRows,
+	}
+}
+
+// classifyMySQLError 按错误码分类 https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+func classifyMySQLError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return nil
+	}
+	switch mysqlErr.Number {
+	case 1062, 1586:
+		return ErrDuplicateKey
+	case 1216, 1217, 1451, 1452:
+		return ErrForeignKey
+	case 1213:
+		return ErrDeadlock
+	case 1205, 3572: // 3572是NOWAIT加锁失败
+		return ErrLockTimeout
+	case 1054:
+		return ErrColumnNotFound
+	}
+	return nil
+}
+
+// ClassifyPostgreSQLError 按SQLSTATE分类，pgx与lib/pq的错误都实现了SQLState方法，
+// 供包外的PostgreSQL方言在ClassifyError中调用
+func ClassifyPostgreSQLError(err error) error {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+	switch pgErr.SQLState() {
+	case "23505":
+		return ErrDuplicateKey
+	case "23503":
+		return ErrForeignKey
+	case "40P01":
+		return ErrDeadlock
+	case "55P03":
+		return ErrLockTimeout
+	case "42703":
+		return ErrColumnNotFound
+	}
+	return nil
+}
+
+// classifySQLiteError SQLite驱动的错误没有统一的类型，按错误信息分类
+func classifySQLiteError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "UNIQUE constraint failed"):
+		return ErrDuplicateKey
+	case strings.Contains(msg, "FOREIGN KEY constraint failed"):
+		return ErrForeignKey
+	case strings.Contains(msg, "database is locked"), strings.Contains(msg, "database table is locked"):
+		return ErrLockTimeout
+	case strings.Contains(msg, "no such column"):
+		return ErrColumnNotFound
+	}
+	return nil
+}
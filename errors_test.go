@@ -0,0 +1,137 @@
+package simple_orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/simple_orm/model"
+	"github.com/simple_orm/valuer"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type pgError struct {
+	code string
+}
+
+func (p *pgError) Error() string {
+	return "pg error " + p.code
+}
+
+func (p *pgError) SQLState() string {
+	return p.code
+}
+
+// customDialect 包外实现的方言嵌入BaseDialect
+type customDialect struct {
+	BaseDialect
+}
+
+func (c *customDialect) Name() string {
+	return "custom"
+}
+
+func (c *customDialect) Upsert(builder *Builder, upsert *UpsertKey) error {
+	return nil
+}
+
+// pgDialect 包外的PostgreSQL方言通过ClassifyPostgreSQLError分类错误
+type pgDialect struct {
+	customDialect
+}
+
+func (p *pgDialect) ClassifyError(err error) error {
+	if kind := ClassifyPostgreSQLError(err); kind != nil {
+		return kind
+	}
+	return p.BaseDialect.ClassifyError(err)
+}
+
+func TestDialect_ClassifyError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dialect  Dialect
+		err      error
+		wantKind error
+	}{
+		{name: "mysql duplicate", dialect: mySQLDialect, err: &mysql.MySQLError{Number: 1062}, wantKind: ErrDuplicateKey},
+		{name: "mysql foreign key", dialect: mySQLDialect, err: &mysql.MySQLError{Number: 1452}, wantKind: ErrForeignKey},
+		{name: "mysql deadlock", dialect: mySQLDialect, err: &mysql.MySQLError{Number: 1213}, wantKind: ErrDeadlock},
+		{name: "mysql lock timeout", dialect: mySQLDialect, err: &mysql.MySQLError{Number: 1205}, wantKind: ErrLockTimeout},
+		{name: "mysql unknown column", dialect: mySQLDialect, err: &mysql.MySQLError{Number: 1054}, wantKind: ErrColumnNotFound},
+		{name: "mysql other", dialect: mySQLDialect, err: &mysql.MySQLError{Number: 1064}},
+		{name: "mysql valuer column", dialect: mySQLDialect, err: valuer.ColumnsNotExists, wantKind: ErrColumnNotFound},
+		// 其他数据库的规则不生效
+		{name: "mysql sqlite message", dialect: mySQLDialect, err: errors.New("database is locked")},
+		{name: "mysql postgres error", dialect: mySQLDialect, err: &pgError{code: "23505"}},
+		{name: "postgres duplicate", dialect: &pgDialect{}, err: &pgError{code: "23505"}, wantKind: ErrDuplicateKey},
+		{name: "postgres foreign key", dialect: &pgDialect{}, err: &pgError{code: "23503"}, wantKind: ErrForeignKey},
+		{name: "postgres deadlock", dialect: &pgDialect{}, err: &pgError{code: "40P01"}, wantKind: ErrDeadlock},
+		{name: "postgres lock not available", dialect: &pgDialect{}, err: &pgError{code: "55P03"}, wantKind: ErrLockTimeout},
+		{name: "postgres undefined column", dialect: &pgDialect{}, err: &pgError{code: "42703"}, wantKind: ErrColumnNotFound},
+		{name: "postgres valuer column", dialect: &pgDialect{}, err: valuer.ColumnsNotExists, wantKind: ErrColumnNotFound},
+		{name: "postgres sqlite message", dialect: &pgDialect{}, err: errors.New("no such column: age")},
+		{name: "sqlite unique", dialect: &SQLiteDialect{}, err: errors.New("UNIQUE constraint failed: user.email"), wantKind: ErrDuplicateKey},
+		{name: "sqlite foreign key", dialect: &SQLiteDialect{}, err: errors.New("FOREIGN KEY constraint failed"), wantKind: ErrForeignKey},
+		{name: "sqlite locked", dialect: &SQLiteDialect{}, err: errors.New("database is locked"), wantKind: ErrLockTimeout},
+		{name: "sqlite no column", dialect: &SQLiteDialect{}, err: errors.New("no such column: age"), wantKind: ErrColumnNotFound},
+		{name: "sqlite mysql error", dialect: &SQLiteDialect{}, err: &mysql.MySQLError{Number: 1062}},
+		{name: "base valuer column", dialect: &customDialect{}, err: valuer.ColumnsNotExists, wantKind: ErrColumnNotFound},
+		{name: "base unknown", dialect: &customDialect{}, err: &mysql.MySQLError{Number: 1062}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantKind, tc.dialect.ClassifyError(tc.err))
+		})
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// 驱动错误分类后仍可以取出原始错误
+	mock.ExpectExec("INSERT .*").WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1'"})
+	_, err = NewInserter[model.TestModel](db).Values(&model.TestModel{Id: 1}).Exec(ctx)
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	var mysqlErr *mysql.MySQLError
+	assert.True(t, errors.As(err, &mysqlErr))
+	assert.Equal(t, uint16(1062), mysqlErr.Number)
+	assert.True(t, strings.Contains(err.Error(), "INSERT INTO `test_model`"))
+
+	// 没有数据
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}))
+	_, err = NewSelector[model.TestModel](db).Get(ctx)
+	assert.True(t, errors.Is(err, ErrNoRows))
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	var queryErr *QueryError
+	assert.True(t, errors.As(err, &queryErr))
+	assert.Equal(t, "SELECT * FROM `test_model`;", queryErr.SQL)
+
+	// 结果中的列不存在
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"invalid"}).AddRow(1))
+	_, err = NewSelector[model.TestModel](db).Get(ctx)
+	assert.True(t, errors.Is(err, ErrColumnNotFound))
+	assert.True(t, errors.Is(err, valuer.ColumnsNotExists))
+
+	// 无法分类的错误同样附带SQL
+	unknown := errors.New("unknown")
+	mock.ExpectQuery("SELECT .*").WillReturnError(unknown)
+	_, err = NewSelector[model.TestModel](db).GetMul(ctx)
+	assert.Equal(t, &QueryError{SQL: "SELECT * FROM `test_model`;", Err: unknown}, err)
+	assert.True(t, errors.Is(err, unknown))
+	assert.False(t, errors.Is(err, ErrDuplicateKey))
+	assert.Equal(t, "unknown, sql: SELECT * FROM `test_model`;", err.Error())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,41 @@
+package simple_orm
+
+import (
+	"context"
+	"github.com/hashicorp/go-multierror"
+)
+
+// 实体可以选择实现以下接口，在对应的持久化事件中被调用。
+// 钩子返回错误会中止操作，在事务中时同时回滚事务
+
+// BeforeInsertHook 在构造INSERT语句前调用，可以修改实体
+type BeforeInsertHook interface {
+	BeforeInsert(ctx context.Context, session Session) error
+}
+
+// AfterInsertHook 在INSERT执行成功后调用，不在事务中时数据已经写入，无法撤销
+type AfterInsertHook interface {
+	AfterInsert(ctx context.Context, session Session) error
+}
+
+// AfterFindHook 在查询结果写入实体后调用
+type AfterFindHook interface {
+	AfterFind(ctx context.Context, session Session) error
+}
+
+// BeforeDeleteHook 在DELETE执行前调用。DELETE按条件删除，没有具体实体，钩子在T的零值上调用
+type BeforeDeleteHook interface {
+	BeforeDelete(ctx context.Context, session Session) error
+}
+
+// abortByHook 钩子出错时，若在事务中则回滚事务
+func abortByHook(session Session, err error) error {
+	tx, ok := session.(*TX)
+	if !ok {
+		return err
+	}
+	if txErr := tx.Rollback(); txErr != nil {
+		err = multierror.Append(err, txErr)
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package simple_orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type HookModel struct {
+	Id        int64
+	FirstName string
+}
+
+// hookEvents 记录钩子的调用顺序，实体的字段都会被当作列，因此记录在包变量中
+var hookEvents []string
+
+var errInsertHook = errors.New("invalid entity")
+
+func (h *HookModel) BeforeInsert(ctx context.Context, session Session) error {
+	hookEvents = append(hookEvents, "BeforeInsert")
+	if h.Id < 0 {
+		return errInsertHook
+	}
+	h.FirstName = "hooked"
+	return nil
+}
+
+func (h *HookModel) AfterInsert(ctx context.Context, session Session) error {
+	hookEvents = append(hookEvents, "AfterInsert")
+	return nil
+}
+
+func (h *HookModel) AfterFind(ctx context.Context, session Session) error {
+	hookEvents = append(hookEvents, "AfterFind")
+	return nil
+}
+
+type DeleteHookModel struct {
+	Id int64
+}
+
+var errDeleteHook = errors.New("delete forbidden")
+
+func (d *DeleteHookModel) BeforeDelete(ctx context.Context, session Session) error {
+	return errDeleteHook
+}
+
+func TestHook_Insert(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// BeforeInsert 修改的字段写入SQL
+	mock.ExpectExec("INSERT INTO `hook_model`").
+		WithArgs(int64(1), "hooked").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	hookEvents = nil
+	_, err = NewInserter[HookModel](db).Values(&HookModel{Id: 1}).Exec(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert"}, hookEvents)
+
+	// 钩子出错时不执行INSERT，事务被回滚
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = db.doTx(context.Background(), func(ctx context.Context, tx *TX) error {
+		_, err := NewInserter[HookModel](tx).Values(&HookModel{Id: -1}).Exec(ctx)
+		return err
+	}, &sql.TxOptions{})
+	assert.Equal(t, errInsertHook, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestHook_AfterFind(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(
+		sqlmock.NewRows([]string{"Id", "FirstName"}).AddRow(1, "Deng"))
+	hookEvents = nil
+	_, err = NewSelector[HookModel](db).Get(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AfterFind"}, hookEvents)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(
+		sqlmock.NewRows([]string{"Id", "FirstName"}).AddRow(1, "Deng").AddRow(2, "Tom"))
+	hookEvents = nil
+	_, err = NewSelector[HookModel](db).GetMul(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AfterFind", "AfterFind"}, hookEvents)
+}
+
+func TestHook_BeforeDelete(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 钩子拒绝删除，不会执行DELETE
+	_, err = NewDeleter[DeleteHookModel](db).Where(NewColumn("Id").EQ(1)).Exec(context.Background())
+	assert.Equal(t, errDeleteHook, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
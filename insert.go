@@ -4,18 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"github.com/simple_orm/master_slave"
 )
 
 type Insert[T any] struct {
 	Builder         // builder是Insert & Select公共部分
 	core            // core中是元数据信息
-	session session // session是db或tx
+	session Session // session是db或tx
 	values  []any
 	columns []string
 	upsert  *UpsertKey
 }
 
-func NewInserter[T any](session session) *Insert[T] {
+func NewInserter[T any](session Session) *Insert[T] {
 	return &Insert[T]{
 		core:    session.getCore(),
 		session: session,
@@ -32,6 +33,13 @@ func (i *Insert[T]) Columns(columns ...string) *Insert[T] {
 	return i
 }
 
+// Use 添加仅对本次查询生效的中间件，在DB级别的中间件之后执行
+func (i *Insert[T]) Use(middleWares ...MiddleWare) *Insert[T] {
+	// 限制容量，避免append时修改DB共享的底层数组
+	i.middleWares = append(i.middleWares[:len(i.middleWares):len(i.middleWares)], middleWares...)
+	return i
+}
+
 // ==============================  UpsertBuilder =============================
 
 func (i *Insert[T]) OnDuplicateKey() *UpsertBuilder[T] {
@@ -71,6 +79,8 @@ func (i *Insert[T]) Build() (*Query, error) {
 	if len(i.columns) == 0 { // 若没有传入列就使用所有的列
 		i.columns = tableModel.ColumnNames
 	}
+	i.columns = withAutoTimeColumns(i.columns, tableModel.CreateTime, tableModel.UpdateTime)
+	i.columns = i.withTenantColumn(i.columns)
 	for idx, column := range i.columns {
 		field, ok := tableModel.Col2Field[column]
 		if !ok {
@@ -100,7 +110,16 @@ func (i *Insert[T]) Build() (*Query, error) {
 	}
 
 	// args
+	now := i.clock()
 	for _, val := range i.values {
+		err = fillAutoTime(val, tableModel, now)
+		if err != nil {
+			return nil, err
+		}
+		err = i.fillTenant(i.tenant, val)
+		if err != nil {
+			return nil, err
+		}
 		internalVal := i.creator(val, i.tableModels)
 		for _, colName := range i.columns {
 			// GetValByColName有两种实现方式反射 & Unsafe，默认是Unsafe
@@ -108,13 +127,17 @@ func (i *Insert[T]) Build() (*Query, error) {
 			if err != nil {
 				return nil, err
 			}
-			i.args = append(i.args, colVal)
+			i.addArg(colName, colVal)
 		}
 	}
 
 	// upsert
 	if i.upsert != nil {
-		err = i.dialect.Upsert(&i.Builder, i.upsert)
+		upsert, err := i.tenantUpsert(i.tenant, i.upsertWithUpdateTime())
+		if err != nil {
+			return nil, err
+		}
+		err = i.dialect.Upsert(&i.Builder, upsert)
 		if err != nil {
 			return nil, err
 		}
@@ -126,35 +149,82 @@ func (i *Insert[T]) Build() (*Query, error) {
 	}, nil
 }
 
-func (i *Insert[T]) execHandler(ctx context.Context, qc *QueryContext) *QueryResult {
-	query, err := i.Build()
-	if err != nil {
-		return &QueryResult{
-			Err: err,
+// withTenantColumn 指定的列中缺少租户字段时补充，不修改调用方的切片
+func (i *Insert[T]) withTenantColumn(columns []string) []string {
+	if i.tenant == nil {
+		return columns
+	}
+	if _, ok := i.tableModels.Col2Field[i.tenant.column]; !ok {
+		return columns
+	}
+	for _, column := range columns {
+		if column == i.tenant.column {
+			return columns
 		}
 	}
-	result, err := i.session.execContext(ctx, query.SQL, query.Args...)
+	return append(columns[:len(columns):len(columns)], i.tenant.column)
+}
+
+// upsertWithUpdateTime 冲突更新时同时刷新更新时间，用户已指定时不覆盖
+func (i *Insert[T]) upsertWithUpdateTime() *UpsertKey {
+	field := i.tableModels.UpdateTime
+	if field == nil {
+		return i.upsert
+	}
+	for _, assign := range i.upsert.assigns {
+		switch e := assign.(type) {
+		case *Column:
+			if e.name == field.TypName {
+				return i.upsert
+			}
+		case *Assignment:
+			if e.ColumnName == field.TypName {
+				return i.upsert
+			}
+		}
+	}
+	assigns := make([]Assignable, 0, len(i.upsert.assigns)+1)
+	assigns = append(assigns, i.upsert.assigns...)
+	// 插入的列总是包含更新时间，取插入的值
+	return &UpsertKey{
+		assigns: append(assigns, NewColumn(field.TypName)),
+	}
+}
+
+func (i *Insert[T]) execHandler(ctx context.Context, qc *QueryContext) *QueryResult {
+	result, err := i.session.execContext(ctx, qc.Query.SQL, qc.Query.Args...)
 	if err != nil {
 		return &QueryResult{
-			Err: err,
+			Err: wrapError(i.dialect, qc.Query.SQL, err),
 		}
 	}
-	return &QueryResult{
-		Result: result,
-		Err:    err,
-	}
+	return newExecResult(result)
 }
 
 func (i *Insert[T]) Exec(ctx context.Context) (sql.Result, error) {
-	var handler HandleFunc = i.execHandler
-	middlewares := i.middleWares
-	for idx := len(middlewares) - 1; idx >= 0; idx-- {
-		handler = middlewares[idx](handler)
+	for _, val := range i.values {
+		if hook, ok := val.(BeforeInsertHook); ok {
+			if err := hook.BeforeInsert(ctx, i.session); err != nil {
+				return nil, abortByHook(i.session, err)
+			}
+		}
+	}
+	qc, err := newQueryContext[T](ctx, i.core, i.session, QueryTypeInsert, i)
+	if err != nil {
+		return nil, err
 	}
-	qc := &QueryContext{}
-	queryResult := handler(ctx, qc)
+	queryResult := chain(i.execHandler, i.middleWares)(ctx, qc)
 	if queryResult.Err != nil {
 		return nil, queryResult.Err
 	}
+	for _, val := range i.values {
+		if hook, ok := val.(AfterInsertHook); ok {
+			if err = hook.AfterInsert(ctx, i.session); err != nil {
+				return nil, abortByHook(i.session, err)
+			}
+		}
+	}
+	// 写入成功后，同一ctx中的读请求在粘滞窗口内走主库
+	master_slave.MarkWrite(ctx)
 	return queryResult.Result.(sql.Result), nil
 }
@@ -1,6 +1,7 @@
 package simple_orm
 
 import (
+	"context"
 	"errors"
 	"github.com/simple_orm/model"
 	"github.com/stretchr/testify/assert"
@@ -125,3 +126,35 @@ func TestInserter_Build(t *testing.T) {
 		})
 	}
 }
+
+func TestInserter_SQLiteUpsert(t *testing.T) {
+	db, err := Open("sqlite3", "file:upsert.db?cache=shared&mode=memory", DBWithDialect(&SQLiteDialect{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.store.Exec("CREATE TABLE `test_model`(`id` INTEGER PRIMARY KEY, `first_name` TEXT, `age` INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _, _ = db.store.Exec("DROP TABLE `test_model`") }()
+	ctx := context.Background()
+	q := NewInserter[model.TestModel](db).Values(&model.TestModel{Id: 1, FirstName: "Deng", Age: 18}).
+		OnDuplicateKey().Update(NewColumn("FirstName"), Assign("Age", 19))
+	query, err := q.Build()
+	assert.Nil(t, err)
+	assert.Equal(t, &Query{
+		SQL: "INSERT INTO `test_model`(`id`,`first_name`,`age`) VALUES(?,?,?) " +
+			"ON CONFLICT DO UPDATE SET `first_name`=excluded.`first_name`,`age`=?;",
+		Args: []any{int64(1), "Deng", int8(18), 19},
+	}, query)
+
+	for _, name := range []string{"Deng", "Da"} {
+		_, err = NewInserter[model.TestModel](db).Values(&model.TestModel{Id: 1, FirstName: name, Age: 18}).
+			OnDuplicateKey().Update(NewColumn("FirstName"), Assign("Age", 19)).Exec(ctx)
+		assert.Nil(t, err)
+	}
+	got, err := NewSelector[model.TestModel](db).Where(NewColumn("Id").EQ(1)).Get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "Da", got.FirstName)
+	assert.Equal(t, int8(19), got.Age)
+}
@@ -0,0 +1,69 @@
+package simple_orm
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Iterator 逐行读取查询结果，不会将所有数据加载到内存。使用完毕后必须Close以释放连接
+//
+//	it, err := NewSelector[User](db).Iter(ctx)
+//	defer it.Close()
+//	for it.Next() {
+//		user := it.Value()
+//	}
+//	err = it.Err()
+type Iterator[T any] struct {
+	ctx     context.Context
+	dialect Dialect
+	query   string // 执行的SQL，用于包装错误
+	session Session
+	rows    *sql.Rows
+	scan    rowScanner[T]
+	cur     *T
+	err     error
+}
+
+// Next 读取下一行，没有数据或出错时返回false并关闭rows
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.rows.Next() {
+		// 读取过程中出现的错误，如连接中断
+		it.err = wrapError(it.dialect, it.query, it.rows.Err())
+		_ = it.rows.Close()
+		return false
+	}
+	tp, err := it.scan(it.rows)
+	if err == nil {
+		if hook, ok := any(tp).(AfterFindHook); ok {
+			if err = hook.AfterFind(it.ctx, it.session); err != nil {
+				err = abortByHook(it.session, err)
+			}
+		}
+	}
+	if err != nil {
+		it.err = wrapError(it.dialect, it.query, err)
+		it.cur = nil
+		_ = it.rows.Close()
+		return false
+	}
+	it.cur = tp
+	return true
+}
+
+// Value 当前行，需要在Next返回true后调用
+func (it *Iterator[T]) Value() *T {
+	return it.cur
+}
+
+// Err 迭代过程中的错误，应在Next返回false后检查
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close 释放连接，可以重复调用
+func (it *Iterator[T]) Close() error {
+	return it.rows.Close()
+}
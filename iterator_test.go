@@ -0,0 +1,92 @@
+package simple_orm
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSelector_Iter(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	// 流式查询同样经过中间件
+	streams := 0
+	db, err := OpenDB(mockDB, DBWithMiddleWare(func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			if qc.Stream {
+				streams++
+			}
+			return next(ctx, qc)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(
+		sqlmock.NewRows([]string{"Id", "FirstName"}).AddRow(1, "Deng").AddRow(2, "Tom")).
+		RowsWillBeClosed()
+	it, err := NewSelector[model.TestModel](db).Iter(context.Background())
+	assert.Nil(t, err)
+	names := make([]string, 0)
+	for it.Next() {
+		names = append(names, it.Value().FirstName)
+	}
+	assert.Nil(t, it.Err())
+	assert.Nil(t, it.Close())
+	assert.Equal(t, []string{"Deng", "Tom"}, names)
+	assert.Equal(t, 1, streams)
+
+	// 读取中途出错
+	rowErr := errors.New("connection reset")
+	mock.ExpectQuery("SELECT .*").WillReturnRows(
+		sqlmock.NewRows([]string{"Id"}).AddRow(1).AddRow(2).RowError(1, rowErr)).
+		RowsWillBeClosed()
+	cnt := 0
+	err = NewSelector[model.TestModel](db).Each(context.Background(), func(m *model.TestModel) error {
+		cnt++
+		return nil
+	})
+	assert.Equal(t, &QueryError{SQL: "SELECT * FROM `test_model`;", Err: rowErr}, err)
+	assert.Equal(t, 1, cnt)
+
+	// 回调出错时停止迭代
+	fnErr := errors.New("stop")
+	mock.ExpectQuery("SELECT .*").WillReturnRows(
+		sqlmock.NewRows([]string{"Id"}).AddRow(1).AddRow(2)).
+		RowsWillBeClosed()
+	cnt = 0
+	err = NewSelector[model.TestModel](db).Each(context.Background(), func(m *model.TestModel) error {
+		cnt++
+		return fnErr
+	})
+	assert.Equal(t, fnErr, err)
+	assert.Equal(t, 1, cnt)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestSelector_GetMulRowsErr(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rowErr := errors.New("connection reset")
+	mock.ExpectQuery("SELECT .*").WillReturnRows(
+		sqlmock.NewRows([]string{"Id"}).AddRow(1).AddRow(2).RowError(1, rowErr)).
+		RowsWillBeClosed()
+	_, err = NewSelector[model.TestModel](db).GetMul(context.Background())
+	assert.Equal(t, &QueryError{SQL: "SELECT * FROM `test_model`;", Err: rowErr}, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,100 @@
+package simple_orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSelector_Lock(t *testing.T) {
+	db := memoryDB4UnitTest(t)
+	sqliteDB, err := Open("sqlite3", "file:test.db?cache=shared&mode=memory", DBWithDialect(&SQLiteDialect{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name      string
+		q         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "for update",
+			q:    NewSelector[model.TestModel](db).Where(NewColumn("Id").EQ(1)).ForUpdate(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` = ? FOR UPDATE;",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "for share nowait",
+			q:    NewSelector[model.TestModel](db).ForShare().NoWait(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` FOR SHARE NOWAIT;",
+			},
+		},
+		{
+			// 行锁位于LIMIT之后
+			name: "skip locked with limit",
+			q:    NewSelector[model.TestModel](db).Limit(10).ForUpdate().SkipLocked(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` LIMIT ? FOR UPDATE SKIP LOCKED;",
+				Args: []any{10},
+			},
+		},
+		{
+			name:    "nowait without lock",
+			q:       NewSelector[model.TestModel](db).NoWait(),
+			wantErr: errors.New("[lock] NOWAIT or SKIP LOCKED requires FOR UPDATE or FOR SHARE"),
+		},
+		{
+			name:    "sqlite",
+			q:       NewSelector[model.TestModel](sqliteDB).ForUpdate(),
+			wantErr: errors.New("[lock] sqlite does not support row lock"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.q.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_LockSession(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 不在事务中
+	_, err = NewSelector[model.TestModel](db).ForUpdate().Get(context.Background())
+	assert.Equal(t, ErrLockOutsideTx, err)
+	_, err = NewSelector[model.TestModel](db).ForUpdate().GetMul(context.Background())
+	assert.Equal(t, ErrLockOutsideTx, err)
+
+	// 事务中
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `test_model` FOR UPDATE SKIP LOCKED;").
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	mock.ExpectCommit()
+	err = db.doTx(context.Background(), func(ctx context.Context, tx *TX) error {
+		_, err := NewSelector[model.TestModel](tx).ForUpdate().SkipLocked().GetMul(ctx)
+		return err
+	}, &sql.TxOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -3,6 +3,7 @@ package master_slave
 import (
 	"context"
 	"database/sql"
+	"errors"
 )
 
 const (
@@ -39,16 +40,30 @@ func NewMasterSlaves(master *sql.DB, options ...masterAndSlavesOptions) (*master
 
 func (m *masterAndSlaves) Query(ctx context.Context, query *ShardingQuery) (*sql.Rows, error) {
 	var db *sql.DB
-	// select支持强制走主节点查询
+	// select支持强制走主节点查询，写后粘滞窗口内同样走主节点
 	_, ok := ctx.Value(master).(bool)
-	if ok || m.slaves == nil || len(m.slaves.slaveArr) == 0 {
+	if ok || isSticky(ctx) || m.slaves == nil {
 		db = m.master
 	} else {
 		slaveNode, err := m.slaves.Next()
-		if err != nil {
+		switch {
+		case errors.Is(err, errSlavesEmpty), errors.Is(err, errSlavesLagging): // 没有可用从库时降级到主库
+			db = m.master
+		case err != nil:
 			return nil, err
+		default:
+			db = slaveNode.db
 		}
-		db = slaveNode.db
 	}
 	return db.QueryContext(ctx, query.SQL, query.Args...)
 }
+
+// Exec 写操作走主节点，并刷新ctx中的写后粘滞窗口
+func (m *masterAndSlaves) Exec(ctx context.Context, query *ShardingQuery) (sql.Result, error) {
+	res, err := m.master.ExecContext(ctx, query.SQL, query.Args...)
+	if err != nil {
+		return nil, err
+	}
+	MarkWrite(ctx)
+	return res, nil
+}
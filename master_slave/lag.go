@@ -0,0 +1,66 @@
+package master_slave
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// LagProbe 探测从库的复制延迟
+type LagProbe func(ctx context.Context, db *sql.DB) (time.Duration, error)
+
+// MySQLLagProbe 通过 SHOW SLAVE STATUS 中的 Seconds_Behind_Master 获取延迟
+func MySQLLagProbe(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, "SHOW SLAVE STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errors.New("not a slave")
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	// 列数量很多，只关心其中一列
+	vals := make([]sql.RawBytes, len(cols))
+	dest := make([]any, len(cols))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+	for i, col := range cols {
+		if col != "Seconds_Behind_Master" {
+			continue
+		}
+		// 复制线程未运行时为NULL
+		if vals[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		seconds, err := strconv.ParseInt(string(vals[i]), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("column Seconds_Behind_Master not exists")
+}
+
+// PostgresLagProbe 通过最后一次回放事务的时间获取延迟
+func PostgresLagProbe(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	var seconds float64
+	err := db.QueryRowContext(ctx,
+		"SELECT COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)").Scan(&seconds)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -32,10 +32,22 @@ type SlavesOption func(s *slaves)
 var _ dnsResolver = (*net.Resolver)(nil)
 
 type slaveNode struct {
-	name string
-	db   *sql.DB
+	name    string
+	db      *sql.DB
+	lag     int64 // 最近一次探测到的复制延迟，time.Duration
+	lagging int32 // 延迟超过阈值或探测失败时为1，不参与轮询
 }
 
+// Lag 最近一次探测到的复制延迟
+func (n *slaveNode) Lag() time.Duration {
+	return time.Duration(atomic.LoadInt64(&n.lag))
+}
+
+var (
+	errSlavesLagging = errors.New("all slaves are lagging")
+	errSlavesEmpty   = errors.New("slave arr is empty")
+)
+
 type slaves struct {
 	domain       string        // 从dsn中解析出的domain
 	dnsResolver  dnsResolver   // dns域名解析器，根据dsn中domain查询所有从节点信息，本质是dns的域名解析
@@ -49,6 +61,9 @@ type slaves struct {
 	driver       string        // 数据库驱动
 	lock         sync.RWMutex  // 读写slaveArr需要加锁
 	idx          uint32        // 访问slaveArr的下标，循环计数
+	lagProbe     LagProbe      // 复制延迟探测，为nil时不探测
+	maxLag       time.Duration // 复制延迟阈值，超过后从轮询中摘除
+	closeDelay   time.Duration // 下线从库延迟关闭的时间，等待已取出该节点的查询完成
 }
 
 func WithDriver(driver string) SlavesOption {
@@ -81,18 +96,34 @@ func WithTimeout(interval time.Duration) SlavesOption {
 	}
 }
 
+// WithLagProbe 开启复制延迟探测，延迟超过maxLag的从库不参与轮询
+func WithLagProbe(probe LagProbe, maxLag time.Duration) SlavesOption {
+	return func(s *slaves) {
+		s.lagProbe = probe
+		s.maxLag = maxLag
+	}
+}
+
 func NewSlaves(dsn string, options ...SlavesOption) (*slaves, error) {
 	s := &slaves{
-		dsnResolver: &MysqlDSN{},
-		driver:      "mysql",
-		closeChan:   make(chan struct{}),
-		interval:    time.Second,
-		timeout:     time.Second,
+		driver:     "mysql",
+		closeChan:  make(chan struct{}),
+		interval:   time.Second,
+		timeout:    time.Second,
+		closeDelay: time.Minute,
 	}
 	// 执行用户的option
 	for _, opt := range options {
 		opt(s)
 	}
+	// 未指定dsn解析器时根据驱动选择
+	if s.dsnResolver == nil {
+		resolver, err := newDSNResolver(s.driver)
+		if err != nil {
+			return nil, err
+		}
+		s.dsnResolver = resolver
+	}
 	err := s.dsnResolver.ResolveDSN(dsn)
 	if err != nil {
 		return nil, err
@@ -106,61 +137,160 @@ func NewSlaves(dsn string, options ...SlavesOption) (*slaves, error) {
 	if err != nil {
 		return nil, err
 	}
+	s.probeLag()
 	// 和所有的从节点维护心跳
 	go func() {
-		ticker := time.NewTimer(s.interval)
-		select {
-		case <-ticker.C: // 维护心跳
-			ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-			err = s.getSlaves(ctx)
-			cancel()
-			// 获取列表是 try best
-			if err != nil {
-				log.Print("get slave arr is fail")
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C: // 维护心跳
+				ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+				err := s.getSlaves(ctx)
+				cancel()
+				// 获取列表是 try best
+				if err != nil {
+					log.Print("get slave arr is fail")
+				}
+				s.probeLag()
+			case <-s.closeChan:
+				return
 			}
-		case <-s.closeChan:
-			return
 		}
 	}()
 	return s, nil
 }
 
+// newDSNResolver 根据数据库驱动选择对应的dsn解析器
+func newDSNResolver(driver string) (dsnResolver, error) {
+	switch driver {
+	case "mysql":
+		return &MysqlDSN{}, nil
+	case "postgres", "pgx":
+		return &PostgresDSN{}, nil
+	case "sqlite3", "sqlite":
+		return &SQLiteDSN{}, nil
+	default:
+		return nil, errors.New("no dsn resolver for driver " + driver)
+	}
+}
+
 // 1. dsn解析出domain 2. 一个domain可以对应对个IP地址，将domain解析成对应从库的IP地址列表 3. 将IP地址替换掉域名
 func (s *slaves) getSlaves(ctx context.Context) error {
-	slavesIP, err := s.dnsResolver.LookupHost(ctx, s.domain)
-	if err != nil {
-		return err
+	// 无域名（如SQLite）时DSN本身即唯一的从库
+	slavesIP := []string{""}
+	if s.domain != "" {
+		var err error
+		slavesIP, err = s.dnsResolver.LookupHost(ctx, s.domain)
+		if err != nil {
+			return err
+		}
+	}
+	// DSN未变化的从库复用原有连接池与延迟状态，避免每次心跳都重建连接池
+	s.lock.RLock()
+	oldNodes := make(map[string]*slaveNode, len(s.slaveArr))
+	for i, dsn := range s.slavesDSNArr {
+		oldNodes[dsn] = s.slaveArr[i]
 	}
-	slavesArr := make([]*slaveNode, 0)
-	slavesDSNArr := make([]string, 0)
+	s.lock.RUnlock()
+	slavesArr := make([]*slaveNode, 0, len(slavesIP))
+	slavesDSNArr := make([]string, 0, len(slavesIP))
+	opened := make([]*sql.DB, 0)
 	for i, ip := range slavesIP {
 		slaveDSN := s.dsnResolver.ReplaceDomainByIP(ip)
-		slavesDSNArr = append(slavesDSNArr, slaveDSN)
+		if node, ok := oldNodes[slaveDSN]; ok {
+			delete(oldNodes, slaveDSN)
+			slavesArr = append(slavesArr, node)
+			slavesDSNArr = append(slavesDSNArr, slaveDSN)
+			continue
+		}
 		db, err := sql.Open(s.driver, slaveDSN)
 		if err != nil {
+			for _, db := range opened {
+				_ = db.Close()
+			}
 			return err
 		}
-		slavesArr = append(slavesArr, &slaveNode{
+		opened = append(opened, db)
+		node := &slaveNode{
 			name: strconv.Itoa(i),
 			db:   db,
-		})
+		}
+		// 新节点探测延迟之前不参与轮询
+		if s.lagProbe != nil {
+			node.lagging = 1
+		}
+		slavesArr = append(slavesArr, node)
+		slavesDSNArr = append(slavesDSNArr, slaveDSN)
 	}
 	// 由于domain相同，可能出现读写冲突
 	s.lock.Lock()
 	s.slaveArr = slavesArr
 	s.slavesDSNArr = slavesDSNArr
 	s.lock.Unlock()
+	// 延迟关闭已下线的从库，Next刚返回的节点仍可能被使用
+	for _, node := range oldNodes {
+		db := node.db
+		time.AfterFunc(s.closeDelay, func() {
+			_ = db.Close()
+		})
+	}
 	return nil
 }
 
-// Next 轮训获取从节点
-func (s *slaves) Next() (*slaveNode, error) {
-	if len(s.slaveArr) == 0 {
-		return nil, errors.New("slave arr is empty")
+// probeLag 探测所有从库的复制延迟，探测失败视为延迟过高
+func (s *slaves) probeLag() {
+	if s.lagProbe == nil {
+		return
 	}
+	s.lock.RLock()
+	nodes := s.slaveArr
+	s.lock.RUnlock()
+	for _, node := range nodes {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		lag, err := s.lagProbe(ctx, node.db)
+		cancel()
+		atomic.StoreInt64(&node.lag, int64(lag))
+		if err != nil || lag > s.maxLag {
+			atomic.StoreInt32(&node.lagging, 1)
+			continue
+		}
+		atomic.StoreInt32(&node.lagging, 0)
+	}
+}
+
+// Close 停止心跳与延迟探测，并关闭所有从库的连接池，可以重复调用
+func (s *slaves) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.closeChan)
+		s.lock.Lock()
+		nodes := s.slaveArr
+		s.slaveArr = nil
+		s.slavesDSNArr = nil
+		s.lock.Unlock()
+		for _, node := range nodes {
+			if e := node.db.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
+
+// Next 轮训获取从节点，跳过延迟过高的节点
+func (s *slaves) Next() (*slaveNode, error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
-	atomic.AddUint32(&s.idx, 1)
-	idx := int(s.idx) % len(s.slaveArr)
-	return s.slaveArr[idx], nil
+	if len(s.slaveArr) == 0 {
+		return nil, errSlavesEmpty
+	}
+	for i := 0; i < len(s.slaveArr); i++ {
+		idx := int(atomic.AddUint32(&s.idx, 1)) % len(s.slaveArr)
+		node := s.slaveArr[idx]
+		if atomic.LoadInt32(&node.lagging) == 0 {
+			return node, nil
+		}
+	}
+	return nil, errSlavesLagging
 }
@@ -0,0 +1,221 @@
+package master_slave
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSlaves_Next(t *testing.T) {
+	lagProbe := func(lags map[*sql.DB]time.Duration) LagProbe {
+		return func(ctx context.Context, db *sql.DB) (time.Duration, error) {
+			lag, ok := lags[db]
+			if !ok {
+				return 0, errors.New("probe fail")
+			}
+			return lag, nil
+		}
+	}
+	db1, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db1.Close() }()
+	db2, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db2.Close() }()
+
+	testCases := []struct {
+		name     string
+		lags     map[*sql.DB]time.Duration
+		wantName []string
+		wantErr  error
+	}{
+		{
+			name:     "all healthy",
+			lags:     map[*sql.DB]time.Duration{db1: 0, db2: time.Millisecond},
+			wantName: []string{"1", "0", "1"},
+		},
+		{
+			name:     "one lagging",
+			lags:     map[*sql.DB]time.Duration{db1: time.Minute, db2: 0},
+			wantName: []string{"1", "1", "1"},
+		},
+		{
+			name:     "probe fail",
+			lags:     map[*sql.DB]time.Duration{db1: 0},
+			wantName: []string{"0", "0", "0"},
+		},
+		{
+			name:    "all lagging",
+			lags:    map[*sql.DB]time.Duration{db1: time.Minute, db2: time.Hour},
+			wantErr: errSlavesLagging,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &slaves{
+				slaveArr: []*slaveNode{{name: "0", db: db1}, {name: "1", db: db2}},
+				timeout:  time.Second,
+				lagProbe: lagProbe(tc.lags),
+				maxLag:   time.Second,
+			}
+			s.probeLag()
+			for _, want := range tc.wantName {
+				node, err := s.Next()
+				assert.Nil(t, err)
+				assert.Equal(t, want, node.name)
+			}
+			if tc.wantErr != nil {
+				_, err := s.Next()
+				assert.Equal(t, tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMasterAndSlaves_ReadYourWrites(t *testing.T) {
+	masterDB, masterMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = masterDB.Close() }()
+	slaveDB, slaveMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = slaveDB.Close() }()
+
+	ms, err := NewMasterSlaves(masterDB, WithSlaves(&slaves{
+		slaveArr: []*slaveNode{{name: "0", db: slaveDB}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := &ShardingQuery{SQL: "SELECT * FROM `test_model`;"}
+	ctx := WithReadYourWrites(context.Background(), time.Minute)
+
+	// 写入前读从库
+	slaveMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = ms.Query(ctx, query)
+	assert.Nil(t, err)
+
+	// 写入后窗口内读主库
+	masterMock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err = ms.Exec(ctx, &ShardingQuery{SQL: "INSERT INTO `test_model`(`id`) VALUES(?);", Args: []any{1}})
+	assert.Nil(t, err)
+	masterMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = ms.Query(ctx, query)
+	assert.Nil(t, err)
+
+	// 其他ctx不受影响
+	slaveMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = ms.Query(context.Background(), query)
+	assert.Nil(t, err)
+
+	assert.Nil(t, masterMock.ExpectationsWereMet())
+	assert.Nil(t, slaveMock.ExpectationsWereMet())
+}
+
+type hostsResolver struct {
+	hosts []string
+}
+
+func (h *hostsResolver) LookupHost(ctx context.Context, domain string) ([]string, error) {
+	return h.hosts, nil
+}
+
+func TestSlaves_GetSlaves(t *testing.T) {
+	dsn := &MysqlDSN{}
+	err := dsn.ResolveDSN("root:root@tcp(slave.db:3306)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := &hostsResolver{hosts: []string{"10.0.0.1", "10.0.0.2"}}
+	s := &slaves{
+		domain:      dsn.GetDomain(),
+		driver:      "sqlmock",
+		dnsResolver: hosts,
+		dsnResolver: dsn,
+		timeout:     time.Second,
+		lagProbe: func(ctx context.Context, db *sql.DB) (time.Duration, error) {
+			return 0, nil
+		},
+		maxLag:     time.Second,
+		closeDelay: 10 * time.Millisecond,
+	}
+	ctx := context.Background()
+	assert.Nil(t, s.getSlaves(ctx))
+	// 探测延迟之前新节点不参与轮询
+	_, err = s.Next()
+	assert.Equal(t, errSlavesLagging, err)
+	s.probeLag()
+	kept, dropped := s.slaveArr[0], s.slaveArr[1]
+	atomic.StoreInt32(&kept.lagging, 1)
+
+	hosts.hosts = []string{"10.0.0.1", "10.0.0.3"}
+	assert.Nil(t, s.getSlaves(ctx))
+	assert.Len(t, s.slaveArr, 2)
+	// DSN未变化的节点复用连接池并保留延迟状态
+	assert.Same(t, kept, s.slaveArr[0])
+	assert.Equal(t, int32(1), atomic.LoadInt32(&kept.lagging))
+	assert.NotSame(t, dropped, s.slaveArr[1])
+	// 下线的节点在延迟之后才关闭连接池
+	closedErr := errors.New("sql: database is closed")
+	assert.NotEqual(t, closedErr, dropped.db.Ping())
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(closedErr, dropped.db.Ping())
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestSlaves_Close(t *testing.T) {
+	s, err := NewSlaves("root:root@tcp(slave.db:3306)/test",
+		WithDriver("sqlmock"),
+		WithDSNResolver(&MysqlDSN{}),
+		WithNetResolver(&hostsResolver{hosts: []string{"10.0.0.1", "10.0.0.2"}}),
+		WithTimeout(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := s.slaveArr
+	assert.Len(t, nodes, 2)
+	assert.Nil(t, s.Close())
+	// 心跳协程退出
+	select {
+	case <-s.closeChan:
+	default:
+		t.Fatal("close chan is not closed")
+	}
+	// 所有从库连接池被关闭
+	for _, node := range nodes {
+		assert.Equal(t, errors.New("sql: database is closed"), node.db.Ping())
+	}
+	_, err = s.Next()
+	assert.Equal(t, errSlavesEmpty, err)
+	// 重复关闭
+	assert.Nil(t, s.Close())
+}
+
+func TestMasterAndSlaves_EmptySlaves(t *testing.T) {
+	masterDB, masterMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = masterDB.Close() }()
+	ms, err := NewMasterSlaves(masterDB, WithSlaves(&slaves{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有从库时降级到主库
+	masterMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = ms.Query(context.Background(), &ShardingQuery{SQL: "SELECT * FROM `test_model`;"})
+	assert.Nil(t, err)
+	assert.Nil(t, masterMock.ExpectationsWereMet())
+}
@@ -0,0 +1,27 @@
+package master_slave
+
+import (
+	"errors"
+)
+
+// SQLiteDSN SQLite的DSN是文件路径（如 file:test.db?cache=shared），没有网络地址，
+// 因此域名为空，从库只有文件本身
+type SQLiteDSN struct {
+	dsn string
+}
+
+func (s *SQLiteDSN) ResolveDSN(dsn string) error {
+	if dsn == "" {
+		return errors.New("sqlite dsn is empty")
+	}
+	s.dsn = dsn
+	return nil
+}
+
+func (s *SQLiteDSN) GetDomain() string {
+	return ""
+}
+
+func (s *SQLiteDSN) ReplaceDomainByIP(ip string) string {
+	return s.dsn
+}
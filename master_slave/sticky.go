@@ -0,0 +1,42 @@
+package master_slave
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type stickyKey struct{}
+
+// sticky 写后读一致性：在写入后的window时间内，同一个ctx的读请求都走主库
+type sticky struct {
+	window time.Duration
+	until  int64 // 粘滞到期时间，UnixNano
+}
+
+// WithReadYourWrites 在ctx中开启写后读粘滞窗口，需要在写入前调用，
+// 之后同一ctx中的Insert/Delete/事务提交会自动刷新窗口
+func WithReadYourWrites(ctx context.Context, window time.Duration) context.Context {
+	if _, ok := ctx.Value(stickyKey{}).(*sticky); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, stickyKey{}, &sticky{window: window})
+}
+
+// MarkWrite 记录一次写入，ctx中没有开启粘滞窗口时什么也不做
+func MarkWrite(ctx context.Context) {
+	st, ok := ctx.Value(stickyKey{}).(*sticky)
+	if !ok {
+		return
+	}
+	atomic.StoreInt64(&st.until, time.Now().Add(st.window).UnixNano())
+}
+
+// isSticky ctx是否处于写后的粘滞窗口内
+func isSticky(ctx context.Context) bool {
+	st, ok := ctx.Value(stickyKey{}).(*sticky)
+	if !ok {
+		return false
+	}
+	return time.Now().UnixNano() < atomic.LoadInt64(&st.until)
+}
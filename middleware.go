@@ -1,16 +1,195 @@
 package simple_orm
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/simple_orm/model"
+	"reflect"
+)
 
 type QueryResult struct {
-	Result any
-	Err    error
+	Result       any
+	Err          error
+	RowsAffected int64 // 仅写操作有值
+	LastInsertId int64 // 仅写操作有值
 }
 
+// QueryContext 中间件可见的查询上下文，进入中间件链之前SQL已经构造完成
 type QueryContext struct {
-	Builder QueryBuilder
+	Type    string            // 语句类型，SELECT/INSERT/DELETE/RAW
+	Builder QueryBuilder      // 构造SQL的builder
+	Model   *model.TableModel // 表的元数据，RAW语句且T不是模型时为nil
+	Query   *Query            // 已构造好的SQL，中间件不必再调用Builder.Build
+	Dialect string            // 方言名称，如mysql
+	// ArgColumns 与Query.Args一一对应的列名（模型字段名），参数没有对应列时为空字符串，原生查询为nil
+	ArgColumns []string
+	// WhereColumns WHERE中引用的列名（模型字段名），没有WHERE或原生查询时为nil
+	WhereColumns []string
+	// Limit SELECT的LIMIT，0表示没有LIMIT
+	Limit int
+	// Multi 查询返回多行（GetMul），结果是[]*T，否则是*T
+	Multi bool
+	// Count COUNT(*)查询（Page的总数），只返回一行，结果是*int64
+	Count bool
+	// Stream 流式查询（Iter/Each），结果是*Iterator[T]，只能读取一次，中间件不应缓存或替换
+	Stream bool
+	// ResultType 查询结果的类型，如*T、[]*V（Pluck），中间件替换结果时必须与之一致，写操作为nil
+	ResultType reflect.Type
+	// TX 查询所在的事务，不在事务中时为nil
+	TX *TX
+	// Attempt 重试的次数，首次执行为0
+	Attempt int
 }
 
 type MiddleWare func(next HandleFunc) HandleFunc
 
 type HandleFunc func(ctx context.Context, qc *QueryContext) *QueryResult
+
+// chain 将中间件包装在handler外层，middleWares[0]在最外层，最先执行
+func chain(handler HandleFunc, middleWares []MiddleWare) HandleFunc {
+	for i := len(middleWares) - 1; i >= 0; i-- {
+		handler = middleWares[i](handler)
+	}
+	return handler
+}
+
+// namedMiddleWare 带名字的中间件，可以声明自己位于哪些中间件之前或之后
+type namedMiddleWare struct {
+	name       string
+	middleWare MiddleWare
+	before     []string // 位于这些中间件之前，即更外层
+	after      []string // 位于这些中间件之后，即更内层
+}
+
+type MiddleWareOption func(m *namedMiddleWare)
+
+// MiddleWareBefore 中间件在names之前执行
+func MiddleWareBefore(names ...string) MiddleWareOption {
+	return func(m *namedMiddleWare) {
+		m.before = append(m.before, names...)
+	}
+}
+
+// MiddleWareAfter 中间件在names之后执行
+func MiddleWareAfter(names ...string) MiddleWareOption {
+	return func(m *namedMiddleWare) {
+		m.after = append(m.after, names...)
+	}
+}
+
+// sortMiddleWares 按照声明的先后关系排序，没有约束的中间件保持注册顺序
+func sortMiddleWares(entries []*namedMiddleWare) ([]MiddleWare, error) {
+	index := make(map[string]int, len(entries))
+	for i, e := range entries {
+		if e.name == "" {
+			continue
+		}
+		if _, ok := index[e.name]; ok {
+			return nil, errors.New("[middleware] duplicate name " + e.name)
+		}
+		index[e.name] = i
+	}
+	// deps[i] 是必须排在i之前的中间件
+	deps := make([]map[int]struct{}, len(entries))
+	for i := range entries {
+		deps[i] = map[int]struct{}{}
+	}
+	for i, e := range entries {
+		for _, name := range e.before {
+			j, ok := index[name]
+			if !ok {
+				return nil, errors.New("[middleware] unknown name " + name)
+			}
+			deps[j][i] = struct{}{}
+		}
+		for _, name := range e.after {
+			j, ok := index[name]
+			if !ok {
+				return nil, errors.New("[middleware] unknown name " + name)
+			}
+			deps[i][j] = struct{}{}
+		}
+	}
+	res := make([]MiddleWare, 0, len(entries))
+	placed := make([]bool, len(entries))
+	for len(res) < len(entries) {
+		next := -1
+		for i := range entries {
+			if placed[i] {
+				continue
+			}
+			ready := true
+			for j := range deps[i] {
+				if !placed[j] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return nil, errors.New("[middleware] circular order")
+		}
+		placed[next] = true
+		res = append(res, entries[next].middleWare)
+	}
+	return res, nil
+}
+
+// newQueryContext 构造SQL并填充元数据。Build会改写builder内部状态，因此只能调用一次
+func newQueryContext[T any](ctx context.Context, core core, session Session, typ string, builder QueryBuilder) (*QueryContext, error) {
+	// 原生查询无法追加租户条件
+	if b, ok := builder.(interface{ bindTenant(tenant any) }); ok && core.tenant != nil && typ != QueryTypeRaw {
+		if tenant, ok := core.tenant.extract(ctx); ok {
+			b.bindTenant(tenant)
+		}
+	}
+	query, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	tableModel, err := core.r.Get(new(T))
+	// 原生查询的结果不一定是模型
+	if err != nil && typ != QueryTypeRaw {
+		return nil, err
+	}
+	qc := &QueryContext{
+		Type:    typ,
+		Builder: builder,
+		Model:   tableModel,
+		Query:   query,
+		Dialect: core.dialect.Name(),
+	}
+	if tx, ok := session.(*TX); ok {
+		qc.TX = tx
+	}
+	if b, ok := builder.(interface{ argColumns() []string }); ok {
+		qc.ArgColumns = b.argColumns()
+	}
+	if b, ok := builder.(interface{ whereColumns() []string }); ok {
+		qc.WhereColumns = b.whereColumns()
+	}
+	if b, ok := builder.(interface{ limitValue() int }); ok {
+		qc.Limit = b.limitValue()
+	}
+	return qc, nil
+}
+
+// newExecResult 将sql.Result转换为QueryResult，写入影响行数与自增ID
+func newExecResult(result sql.Result) *QueryResult {
+	res := &QueryResult{
+		Result: result,
+	}
+	// 部分驱动不支持，获取失败时保持零值
+	if affected, err := result.RowsAffected(); err == nil {
+		res.RowsAffected = affected
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		res.LastInsertId = id
+	}
+	return res
+}
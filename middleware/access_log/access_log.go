@@ -16,13 +16,7 @@ func NewLogMiddleWare() *LogMiddleWare {
 func (l *LogMiddleWare) Build() simple_orm.MiddleWare {
 	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
 		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
-			query, err := qc.Builder.Build()
-			if err != nil {
-				return &simple_orm.QueryResult{
-					Err: err,
-				}
-			}
-			fmt.Println(query.SQL)
+			fmt.Println(qc.Query.SQL)
 			return next(ctx, qc)
 		}
 	}
@@ -0,0 +1,162 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"github.com/simple_orm"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	StateClosed   State = iota // 正常放行
+	StateOpen                  // 熔断，直接拒绝
+	StateHalfOpen              // 冷却结束，放行少量探测请求
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	ErrCircuitOpen      = errors.New("[breaker] circuit open")
+	ErrConcurrencyLimit = errors.New("[breaker] too many in-flight queries")
+)
+
+// RejectedError 请求被熔断或限流时快速失败返回的错误，可以用errors.Is判断原因
+type RejectedError struct {
+	Source string // 数据源，见BreakerMiddleWare的key
+	Err    error  // ErrCircuitOpen 或 ErrConcurrencyLimit
+}
+
+func (r *RejectedError) Error() string {
+	if r.Source == "" {
+		return r.Err.Error()
+	}
+	return r.Err.Error() + ", source: " + r.Source
+}
+
+func (r *RejectedError) Unwrap() error {
+	return r.Err
+}
+
+// Stats 单个数据源的状态
+type Stats struct {
+	State               State
+	InFlight            int
+	ConsecutiveFailures int
+	Requests            int64
+	Failures            int64
+	Rejected            int64
+}
+
+// circuit 单个数据源的熔断器与信号量
+type circuit struct {
+	lock                sync.Mutex
+	state               State
+	openedAt            time.Time
+	halfOpenInFlight    int
+	consecutiveFailures int
+	requests            int64
+	failures            int64
+	rejected            int64
+	sem                 chan struct{} // 为nil时不限制并发
+}
+
+// allow 返回请求是否放行，以及放行时该请求是否为半开状态下的探测请求
+func (c *circuit) allow(b *BreakerMiddleWare) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.requests++
+	if c.state == StateOpen {
+		if b.now().Sub(c.openedAt) < b.cooldown {
+			c.rejected++
+			return false, ErrCircuitOpen
+		}
+		c.state = StateHalfOpen
+		c.halfOpenInFlight = 0
+	}
+	probe := c.state == StateHalfOpen
+	if probe {
+		if c.halfOpenInFlight >= b.halfOpenMax {
+			c.rejected++
+			return false, ErrCircuitOpen
+		}
+		c.halfOpenInFlight++
+	}
+	if c.sem != nil {
+		select {
+		case c.sem <- struct{}{}:
+		default:
+			if probe {
+				c.halfOpenInFlight--
+			}
+			c.rejected++
+			return false, ErrConcurrencyLimit
+		}
+	}
+	return probe, nil
+}
+
+func (c *circuit) done(b *BreakerMiddleWare, probe bool, failed bool) {
+	if c.sem != nil {
+		<-c.sem
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if probe {
+		c.halfOpenInFlight--
+	}
+	if failed {
+		c.failures++
+		c.consecutiveFailures++
+		// 半开状态下探测失败，或关闭状态下连续失败达到阈值
+		if c.state == StateHalfOpen || (c.state == StateClosed && c.consecutiveFailures >= b.failureThreshold) {
+			c.state = StateOpen
+			c.openedAt = b.now()
+		}
+		return
+	}
+	c.consecutiveFailures = 0
+	if c.state == StateHalfOpen {
+		c.state = StateClosed
+	}
+}
+
+func (c *circuit) stats() Stats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Stats{
+		State:               c.state,
+		InFlight:            len(c.sem),
+		ConsecutiveFailures: c.consecutiveFailures,
+		Requests:            c.requests,
+		Failures:            c.failures,
+		Rejected:            c.rejected,
+	}
+}
+
+// isFailure 默认除了调用方主动取消，以及没有数据、唯一键冲突等由调用方或数据引起的错误外，都视为失败
+func isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, target := range []error{context.Canceled, simple_orm.ErrNoRows, simple_orm.ErrDuplicateKey,
+		simple_orm.ErrForeignKey, simple_orm.ErrStaleObject} {
+		if errors.Is(err, target) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package breaker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	now := time.Unix(0, 0)
+	b := NewBreakerMiddleWare(WithFailureThreshold(2), WithCooldown(time.Second), WithPerTable())
+	b.now = func() time.Time { return now }
+	db, err := simple_orm.OpenDB(mockDB, simple_orm.DBWithMiddleWare(b.Build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func() error {
+		_, err := simple_orm.NewSelector[model.TestModel](db).Get(context.Background())
+		return err
+	}
+
+	// 连续失败两次后熔断
+	mock.ExpectQuery("SELECT .*").WillReturnError(errors.New("mock error"))
+	mock.ExpectQuery("SELECT .*").WillReturnError(errors.New("mock error"))
+	assert.Equal(t, errors.New("mock error"), errors.Unwrap(get()))
+	assert.Equal(t, StateClosed, b.Stats()["test_model"].State)
+	assert.Equal(t, errors.New("mock error"), errors.Unwrap(get()))
+	assert.Equal(t, StateOpen, b.Stats()["test_model"].State)
+
+	// 熔断期间快速失败
+	err = get()
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	var rejected *RejectedError
+	assert.True(t, errors.As(err, &rejected))
+	assert.Equal(t, "test_model", rejected.Source)
+
+	// 冷却后探测失败，重新熔断
+	now = now.Add(time.Second)
+	mock.ExpectQuery("SELECT .*").WillReturnError(errors.New("mock error"))
+	assert.Equal(t, errors.New("mock error"), errors.Unwrap(get()))
+	assert.Equal(t, StateOpen, b.Stats()["test_model"].State)
+
+	// 冷却后探测成功，恢复
+	now = now.Add(time.Second)
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	assert.Nil(t, get())
+	stats := b.Stats()["test_model"]
+	assert.Equal(t, StateClosed, stats.State)
+	assert.Equal(t, int64(5), stats.Requests)
+	assert.Equal(t, int64(3), stats.Failures)
+	assert.Equal(t, int64(1), stats.Rejected)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddlewareBuilder_MaxInFlight(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	b := NewBreakerMiddleWare(WithMaxInFlight(1))
+	db, err := simple_orm.OpenDB(mockDB, simple_orm.DBWithMiddleWare(b.Build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery("SELECT .*").WillDelayFor(200 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+
+	done := make(chan error)
+	go func() {
+		_, err := simple_orm.NewSelector[model.TestModel](db).Get(context.Background())
+		done <- err
+	}()
+	// 等待第一个查询占用信号量
+	assert.Eventually(t, func() bool {
+		return b.Stats()[""].InFlight == 1
+	}, time.Second, time.Millisecond)
+	_, err = simple_orm.NewSelector[model.TestModel](db).Get(context.Background())
+	assert.True(t, errors.Is(err, ErrConcurrencyLimit))
+	assert.Nil(t, <-done)
+	assert.Equal(t, 0, b.Stats()[""].InFlight)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddlewareBuilder_Panic(t *testing.T) {
+	b := NewBreakerMiddleWare(WithMaxInFlight(1))
+	handler := b.Build()(func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+		panic("mock panic")
+	})
+	assert.Panics(t, func() {
+		handler(context.Background(), &simple_orm.QueryContext{})
+	})
+	// panic后信号量已归还
+	stats := b.Stats()[""]
+	assert.Equal(t, 0, stats.InFlight)
+	assert.Equal(t, int64(1), stats.Failures)
+}
+
+func TestIsFailure(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "no rows", err: &simple_orm.QueryError{Kind: simple_orm.ErrNoRows, Err: sql.ErrNoRows}, want: false},
+		{name: "duplicate key", err: &simple_orm.QueryError{Kind: simple_orm.ErrDuplicateKey}, want: false},
+		{name: "foreign key", err: &simple_orm.QueryError{Kind: simple_orm.ErrForeignKey}, want: false},
+		{name: "stale object", err: simple_orm.ErrStaleObject, want: false},
+		{name: "deadlock", err: &simple_orm.QueryError{Kind: simple_orm.ErrDeadlock}, want: true},
+		{name: "driver error", err: errors.New("mock error"), want: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, isFailure(tc.err))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package breaker
+
+import (
+	"context"
+	"github.com/simple_orm"
+	"sync"
+	"time"
+)
+
+// BreakerMiddleWare 按数据源熔断与限制并发。中间件注册在DB上，因此默认一个DB是一个数据源，
+// WithPerTable后每张表是一个数据源
+type BreakerMiddleWare struct {
+	failureThreshold int           // 连续失败多少次后熔断
+	cooldown         time.Duration // 熔断持续时间，之后进入半开状态
+	halfOpenMax      int           // 半开状态下同时放行的探测请求数
+	maxInFlight      int           // 单个数据源的最大并发，0表示不限制
+	perTable         bool
+	isFailure        func(err error) bool
+	now              func() time.Time
+
+	lock     sync.Mutex
+	circuits map[string]*circuit
+}
+
+type BreakerOption func(b *BreakerMiddleWare)
+
+// WithFailureThreshold 连续失败threshold次后熔断，默认5次
+func WithFailureThreshold(threshold int) BreakerOption {
+	return func(b *BreakerMiddleWare) {
+		b.failureThreshold = threshold
+	}
+}
+
+// WithCooldown 熔断持续时间，默认10秒
+func WithCooldown(cooldown time.Duration) BreakerOption {
+	return func(b *BreakerMiddleWare) {
+		b.cooldown = cooldown
+	}
+}
+
+// WithHalfOpenMax 半开状态下同时放行的探测请求数，默认1
+func WithHalfOpenMax(max int) BreakerOption {
+	return func(b *BreakerMiddleWare) {
+		b.halfOpenMax = max
+	}
+}
+
+// WithMaxInFlight 单个数据源的最大并发，超过时快速失败
+func WithMaxInFlight(max int) BreakerOption {
+	return func(b *BreakerMiddleWare) {
+		b.maxInFlight = max
+	}
+}
+
+// WithPerTable 每张表单独熔断与限流
+func WithPerTable() BreakerOption {
+	return func(b *BreakerMiddleWare) {
+		b.perTable = true
+	}
+}
+
+// WithFailureFunc 自定义哪些错误计入失败
+func WithFailureFunc(fn func(err error) bool) BreakerOption {
+	return func(b *BreakerMiddleWare) {
+		b.isFailure = fn
+	}
+}
+
+func NewBreakerMiddleWare(opts ...BreakerOption) *BreakerMiddleWare {
+	b := &BreakerMiddleWare{
+		failureThreshold: 5,
+		cooldown:         10 * time.Second,
+		halfOpenMax:      1,
+		isFailure:        isFailure,
+		now:              time.Now,
+		circuits:         map[string]*circuit{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func (b *BreakerMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			key := b.key(qc)
+			c := b.circuit(key)
+			probe, err := c.allow(b)
+			if err != nil {
+				return &simple_orm.QueryResult{
+					Err: &RejectedError{Source: key, Err: err},
+				}
+			}
+			// next发生panic时同样归还信号量，并计为失败
+			failed := true
+			defer func() {
+				c.done(b, probe, failed)
+			}()
+			res := next(ctx, qc)
+			failed = b.isFailure(res.Err)
+			return res
+		}
+	}
+}
+
+// Stats 所有数据源的状态，key是数据源
+func (b *BreakerMiddleWare) Stats() map[string]Stats {
+	b.lock.Lock()
+	circuits := make(map[string]*circuit, len(b.circuits))
+	for k, v := range b.circuits {
+		circuits[k] = v
+	}
+	b.lock.Unlock()
+	res := make(map[string]Stats, len(circuits))
+	for k, v := range circuits {
+		res[k] = v.stats()
+	}
+	return res
+}
+
+func (b *BreakerMiddleWare) key(qc *simple_orm.QueryContext) string {
+	if b.perTable && qc.Model != nil {
+		return qc.Model.TableName
+	}
+	return ""
+}
+
+func (b *BreakerMiddleWare) circuit(key string) *circuit {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		if b.maxInFlight > 0 {
+			c.sem = make(chan struct{}, b.maxInFlight)
+		}
+		b.circuits[key] = c
+	}
+	return c
+}
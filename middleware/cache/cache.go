@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/simple_orm"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+type skipKey struct{}
+
+// SkipCache ctx中的查询不读写缓存
+func SkipCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+// CacheMiddleWare 缓存Selector.Get/GetMul的结果。
+// 失效按表进行：每张表有一个版本号，写操作使版本号+1，旧版本的缓存不再命中，由TTL或LRU淘汰
+type CacheMiddleWare struct {
+	cache    Cache
+	ttl      time.Duration
+	lock     sync.RWMutex
+	versions map[string]uint64 // 表名到版本号
+}
+
+type CacheOption func(c *CacheMiddleWare)
+
+// WithCache 替换默认的LRU缓存
+func WithCache(cache Cache) CacheOption {
+	return func(c *CacheMiddleWare) {
+		c.cache = cache
+	}
+}
+
+// WithTTL 缓存有效期，默认一分钟
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(c *CacheMiddleWare) {
+		c.ttl = ttl
+	}
+}
+
+func NewCacheMiddleWare(opts ...CacheOption) *CacheMiddleWare {
+	c := &CacheMiddleWare{
+		cache:    NewLRUCache(1024),
+		ttl:      time.Minute,
+		versions: map[string]uint64{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *CacheMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			if qc.Model == nil {
+				return next(ctx, qc)
+			}
+			table := qc.Model.TableName
+			switch qc.Type {
+			case simple_orm.QueryTypeSelect:
+				return c.query(ctx, qc, next)
+			case simple_orm.QueryTypeInsert, simple_orm.QueryTypeDelete, simple_orm.QueryTypeUpdate:
+				res := next(ctx, qc)
+				if res.Err != nil {
+					return res
+				}
+				c.Invalidate(table)
+				// 事务提交前其他请求可能把旧数据重新放入缓存，提交后再失效一次
+				if qc.TX != nil {
+					qc.TX.AfterCommit(func() {
+						c.Invalidate(table)
+					})
+				}
+				return res
+			default:
+				return next(ctx, qc)
+			}
+		}
+	}
+}
+
+func (c *CacheMiddleWare) query(ctx context.Context, qc *simple_orm.QueryContext,
+	next simple_orm.HandleFunc) *simple_orm.QueryResult {
+	// 事务中可能读到自己未提交的数据，流式查询的结果只能读取一次，均不使用缓存
+	if skip, _ := ctx.Value(skipKey{}).(bool); skip || qc.TX != nil || qc.Stream {
+		return next(ctx, qc)
+	}
+	key := c.key(qc)
+	if val, ok := c.cache.Get(key); ok {
+		return &simple_orm.QueryResult{
+			Result: cloneResult(val),
+		}
+	}
+	res := next(ctx, qc)
+	if res.Err == nil {
+		c.cache.Set(key, cloneResult(res.Result), c.ttl)
+	}
+	return res
+}
+
+// Invalidate 使表的所有缓存失效
+func (c *CacheMiddleWare) Invalidate(table string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.versions[table]++
+}
+
+func (c *CacheMiddleWare) key(qc *simple_orm.QueryContext) string {
+	table := qc.Model.TableName
+	c.lock.RLock()
+	version := c.versions[table]
+	c.lock.RUnlock()
+	// 同一条SQL可以扫描成不同的类型，如Pluck[int64]与Pluck[string]
+	return fmt.Sprintf("%s:%d:%v:%s:%#v", table, version, qc.ResultType, trimComment(qc.Query.SQL), qc.Query.Args)
+}
+
+// trimComment 去掉结尾的注释，如trace中间件在外层时追加的sqlcommenter注释每次请求都不同。
+// MySQL的/*!...*/会被执行，予以保留
+func trimComment(sql string) string {
+	trimmed := strings.TrimRight(sql, " ;")
+	if !strings.HasSuffix(trimmed, "*/") {
+		return sql
+	}
+	start := strings.LastIndex(trimmed, "/*")
+	if start == -1 || strings.HasPrefix(trimmed[start:], "/*!") {
+		return sql
+	}
+	return strings.TrimRight(trimmed[:start], " ") + sql[len(trimmed):]
+}
+
+// cloneResult 缓存的结果是指针，深拷贝一份，避免调用方修改结果（包括其中的指针、切片与map字段）时修改缓存中的数据
+func cloneResult(val any) any {
+	if val == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(val)).Interface()
+}
+
+// deepCopy 递归拷贝指针、切片、map与结构体的导出字段，未导出字段只能浅拷贝
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.New(v.Elem().Type())
+		cp.Elem().Set(deepCopy(v.Elem()))
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return cp
+	case reflect.Array:
+		cp := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return cp
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return cp
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(deepCopy(v.Elem()))
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if fd := cp.Field(i); fd.CanSet() {
+				fd.Set(deepCopy(v.Field(i)))
+			}
+		}
+		return cp
+	default:
+		return v
+	}
+}
@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := simple_orm.OpenDB(mockDB,
+		simple_orm.DBWithMiddleWare(NewCacheMiddleWare().Build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	get := func() (*model.TestModel, error) {
+		return simple_orm.NewSelector[model.TestModel](db).Where(simple_orm.NewColumn("Id").EQ(1)).Get(ctx)
+	}
+
+	// 第一次查询数据库
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id", "FirstName"}).AddRow(1, "Da"))
+	res, err := get()
+	assert.Nil(t, err)
+	assert.Equal(t, &model.TestModel{Id: 1, FirstName: "Da"}, res)
+	// 修改返回值不影响缓存
+	res.FirstName = "changed"
+
+	// 命中缓存
+	res, err = get()
+	assert.Nil(t, err)
+	assert.Equal(t, &model.TestModel{Id: 1, FirstName: "Da"}, res)
+
+	// GetMul与Get的缓存互不影响
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id", "FirstName"}).AddRow(1, "Da"))
+	resArr, err := simple_orm.NewSelector[model.TestModel](db).Where(simple_orm.NewColumn("Id").EQ(1)).GetMul(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []*model.TestModel{{Id: 1, FirstName: "Da"}}, resArr)
+
+	// 同一条SQL扫描成不同类型时缓存互不影响
+	mock.ExpectQuery("SELECT `id` .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	ids, err := simple_orm.Pluck[int64](ctx, simple_orm.NewSelector[model.TestModel](db), "Id")
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1}, ids)
+	mock.ExpectQuery("SELECT `id` .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	strIds, err := simple_orm.Pluck[string](ctx, simple_orm.NewSelector[model.TestModel](db), "Id")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1"}, strIds)
+
+	// 写操作使缓存失效
+	mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = simple_orm.NewDeleter[model.TestModel](db).Where(simple_orm.NewColumn("Id").EQ(2)).Exec(ctx)
+	assert.Nil(t, err)
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id", "FirstName"}).AddRow(1, "Xiao"))
+	res, err = get()
+	assert.Nil(t, err)
+	assert.Equal(t, &model.TestModel{Id: 1, FirstName: "Xiao"}, res)
+
+	// 跳过缓存
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id", "FirstName"}).AddRow(1, "Ming"))
+	res, err = simple_orm.NewSelector[model.TestModel](db).Where(simple_orm.NewColumn("Id").EQ(1)).Get(SkipCache(ctx))
+	assert.Nil(t, err)
+	assert.Equal(t, &model.TestModel{Id: 1, FirstName: "Ming"}, res)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+type Profile struct {
+	Tags  []string
+	Attrs map[string]string
+	Owner *model.TestModel
+}
+
+func TestCloneResult(t *testing.T) {
+	name := "Da"
+	val := []*Profile{{
+		Tags:  []string{"a"},
+		Attrs: map[string]string{"k": "v"},
+		Owner: &model.TestModel{Id: 1, FirstName: name},
+	}}
+	cp := cloneResult(val).([]*Profile)
+	assert.Equal(t, val, cp)
+	// 修改拷贝中的指针、切片与map字段不影响原值
+	cp[0].Tags[0] = "changed"
+	cp[0].Attrs["k"] = "changed"
+	cp[0].Owner.FirstName = "changed"
+	assert.Equal(t, "a", val[0].Tags[0])
+	assert.Equal(t, "v", val[0].Attrs["k"])
+	assert.Equal(t, "Da", val[0].Owner.FirstName)
+
+	// Pluck与map结果
+	m := []*map[string]any{{"tags": []byte("a")}}
+	mcp := cloneResult(m).([]*map[string]any)
+	(*mcp[0])["tags"].([]byte)[0] = 'b'
+	assert.Equal(t, []byte("a"), (*m[0])["tags"])
+	assert.Nil(t, cloneResult(nil))
+}
+
+func TestLRUCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewLRUCache(2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1, time.Second)
+	c.Set("b", 2, 0)
+	_, _ = c.Get("a")
+	// 容量满时淘汰最久未访问的b
+	c.Set("c", 3, 0)
+	_, ok := c.Get("b")
+	assert.False(t, ok)
+	val, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+
+	// 过期
+	now = now.Add(time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestTrimComment(t *testing.T) {
+	testCases := []struct {
+		sql  string
+		want string
+	}{
+		{sql: "SELECT * FROM `user`;", want: "SELECT * FROM `user`;"},
+		{sql: "SELECT * FROM `user` /*traceparent='00-01'*/;", want: "SELECT * FROM `user`;"},
+		{sql: "SELECT * FROM `user` /*traceparent='00-01'*/", want: "SELECT * FROM `user`"},
+		{sql: "SELECT /*+ MAX_EXECUTION_TIME(1) */ * FROM `user`;", want: "SELECT /*+ MAX_EXECUTION_TIME(1) */ * FROM `user`;"},
+		// 会被执行的注释保留
+		{sql: "SELECT * FROM `user` /*!50000 LIMIT 1 */;", want: "SELECT * FROM `user` /*!50000 LIMIT 1 */;"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.sql, func(t *testing.T) {
+			assert.Equal(t, tc.want, trimComment(tc.sql))
+		})
+	}
+}
+
+func TestMiddlewareBuilder_Comment(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	// 在缓存外层追加每次请求都不同的注释，如trace的sqlcommenter
+	cnt := 0
+	comment := func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			cnt++
+			qc.Query.SQL = strings.TrimSuffix(qc.Query.SQL, ";") + fmt.Sprintf(" /*traceparent='%d'*/;", cnt)
+			return next(ctx, qc)
+		}
+	}
+	db, err := simple_orm.OpenDB(mockDB,
+		simple_orm.DBWithMiddleWare(comment, NewCacheMiddleWare().Build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	for i := 0; i < 2; i++ {
+		res, err := simple_orm.NewSelector[model.TestModel](db).Get(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, &model.TestModel{Id: 1}, res)
+	}
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
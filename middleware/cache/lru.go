@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache 缓存的抽象，可以对接Redis等外部缓存，val需要自行序列化
+type Cache interface {
+	Get(key string) (any, bool)
+	Set(key string, val any, ttl time.Duration)
+}
+
+type lruEntry struct {
+	key      string
+	val      any
+	expireAt time.Time // 零值表示不过期
+}
+
+// LRUCache 基于内存的LRU缓存，容量满时淘汰最久未访问的条目
+type LRUCache struct {
+	lock     sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	now      func() time.Time
+}
+
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    map[string]*list.Element{},
+		now:      time.Now,
+	}
+}
+
+func (l *LRUCache) Get(key string) (any, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expireAt.IsZero() && !l.now().Before(entry.expireAt) {
+		l.removeElement(elem)
+		return nil, false
+	}
+	l.ll.MoveToFront(elem)
+	return entry.val, true
+}
+
+func (l *LRUCache) Set(key string, val any, ttl time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = l.now().Add(ttl)
+	}
+	if elem, ok := l.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.val = val
+		entry.expireAt = expireAt
+		l.ll.MoveToFront(elem)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&lruEntry{
+		key:      key,
+		val:      val,
+		expireAt: expireAt,
+	})
+	for l.capacity > 0 && l.ll.Len() > l.capacity {
+		l.removeElement(l.ll.Back())
+	}
+}
+
+// Len 当前缓存的条目数，包含已过期但未被淘汰的条目
+func (l *LRUCache) Len() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.ll.Len()
+}
+
+// removeElement 调用方需持有锁
+func (l *LRUCache) removeElement(elem *list.Element) {
+	l.ll.Remove(elem)
+	delete(l.items, elem.Value.(*lruEntry).key)
+}
@@ -0,0 +1,143 @@
+package chaos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/simple_orm"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Fault 一条故障规则
+type Fault struct {
+	Table       string        // 表名，为空时匹配所有表
+	Operation   string        // 语句类型，为空时匹配所有语句
+	Probability float64       // 触发概率，[0, 1]
+	Latency     time.Duration // 执行前注入的延迟
+	Err         error         // 注入的错误，不为nil时不执行查询，如driver.ErrBadConn
+	DropResult  bool          // 执行后丢弃结果：SELECT返回空，写操作返回影响行数为0
+}
+
+type faultsKey struct{}
+
+type disabledKey struct{}
+
+// WithFaults 为ctx中的查询追加故障规则，便于在单个测试中控制
+func WithFaults(ctx context.Context, faults ...Fault) context.Context {
+	prev, _ := ctx.Value(faultsKey{}).([]Fault)
+	merged := append(append([]Fault(nil), prev...), faults...)
+	return context.WithValue(ctx, faultsKey{}, merged)
+}
+
+// Disable ctx中的查询不注入任何故障
+func Disable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, disabledKey{}, true)
+}
+
+type ChaosMiddleWare struct {
+	faults []Fault
+	lock   sync.Mutex // rand.Rand不是并发安全的
+	rand   *rand.Rand
+}
+
+// NewChaosMiddleWare faults对所有查询生效，ctx中的规则见WithFaults
+func NewChaosMiddleWare(faults ...Fault) *ChaosMiddleWare {
+	return &ChaosMiddleWare{
+		faults: faults,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (c *ChaosMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			if disabled, _ := ctx.Value(disabledKey{}).(bool); disabled {
+				return next(ctx, qc)
+			}
+			ctxFaults, _ := ctx.Value(faultsKey{}).([]Fault)
+			drop := false
+			for _, faults := range [][]Fault{c.faults, ctxFaults} {
+				for _, f := range faults {
+					if !c.hit(f, qc) {
+						continue
+					}
+					if f.Latency > 0 {
+						if err := sleep(ctx, f.Latency); err != nil {
+							return &simple_orm.QueryResult{Err: err}
+						}
+					}
+					if f.Err != nil {
+						return &simple_orm.QueryResult{Err: f.Err}
+					}
+					drop = drop || f.DropResult
+				}
+			}
+			res := next(ctx, qc)
+			if drop && res.Err == nil {
+				return dropResult(qc, res)
+			}
+			return res
+		}
+	}
+}
+
+func (c *ChaosMiddleWare) hit(f Fault, qc *simple_orm.QueryContext) bool {
+	if f.Operation != "" && f.Operation != qc.Type {
+		return false
+	}
+	if f.Table != "" && (qc.Model == nil || qc.Model.TableName != f.Table) {
+		return false
+	}
+	if f.Probability >= 1 {
+		return true
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.rand.Float64() < f.Probability
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dropResult 丢弃结果，保持结果的类型不变。流式查询的迭代器持有连接，不做替换
+func dropResult(qc *simple_orm.QueryContext, res *simple_orm.QueryResult) *simple_orm.QueryResult {
+	if qc.Stream {
+		return res
+	}
+	switch qc.Type {
+	case simple_orm.QueryTypeSelect, simple_orm.QueryTypeRaw:
+		// COUNT(*)总是返回一行
+		if qc.Count {
+			return &simple_orm.QueryResult{
+				Result: new(int64),
+			}
+		}
+		if qc.Multi {
+			return &simple_orm.QueryResult{
+				Result: reflect.MakeSlice(reflect.TypeOf(res.Result), 0, 0).Interface(),
+			}
+		}
+		return &simple_orm.QueryResult{
+			Err: &simple_orm.QueryError{
+				SQL:  qc.Query.SQL,
+				Kind: simple_orm.ErrNoRows,
+				Err:  sql.ErrNoRows,
+			},
+		}
+	default:
+		return &simple_orm.QueryResult{
+			Result: driver.RowsAffected(0),
+		}
+	}
+}
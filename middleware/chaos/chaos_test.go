@@ -0,0 +1,170 @@
+package chaos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name    string
+		faults  []Fault
+		ctx     context.Context
+		mock    func(mock sqlmock.Sqlmock)
+		exec    func(db *simple_orm.DB, ctx context.Context) (any, error)
+		wantVal any
+		wantErr error
+	}{
+		{
+			name:   "inject error",
+			faults: []Fault{{Operation: simple_orm.QueryTypeSelect, Probability: 1, Err: driver.ErrBadConn}},
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				return simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+			},
+			wantErr: driver.ErrBadConn,
+		},
+		{
+			name:   "other table not affected",
+			faults: []Fault{{Table: "order", Probability: 1, Err: driver.ErrBadConn}},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				return simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+			},
+			wantVal: &model.TestModel{Id: 1},
+		},
+		{
+			name: "fault from context",
+			ctx: WithFaults(context.Background(),
+				Fault{Operation: simple_orm.QueryTypeDelete, Probability: 1, Err: errors.New("lock wait timeout")}),
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				return simple_orm.NewDeleter[model.TestModel](db).Exec(ctx)
+			},
+			wantErr: errors.New("lock wait timeout"),
+		},
+		{
+			name:   "disabled by context",
+			faults: []Fault{{Probability: 1, Err: driver.ErrBadConn}},
+			ctx:    Disable(context.Background()),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				return simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+			},
+			wantVal: &model.TestModel{Id: 1},
+		},
+		{
+			name:   "never",
+			faults: []Fault{{Probability: 0, Err: driver.ErrBadConn}},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				return simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+			},
+			wantVal: &model.TestModel{Id: 1},
+		},
+		{
+			name:   "drop single result",
+			faults: []Fault{{Probability: 1, DropResult: true}},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				return simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+			},
+			wantErr: &simple_orm.QueryError{
+				SQL:  "SELECT * FROM `test_model`;",
+				Kind: simple_orm.ErrNoRows,
+				Err:  sql.ErrNoRows,
+			},
+		},
+		{
+			name:   "drop count result",
+			faults: []Fault{{Probability: 1, DropResult: true}},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT COUNT.*").WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(5))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				return simple_orm.NewSelector[model.TestModel](db).Page(ctx, 1, 10)
+			},
+			wantVal: &simple_orm.Page[model.TestModel]{Items: []*model.TestModel{}, Page: 1, Size: 10},
+		},
+		{
+			name:   "drop multi result",
+			faults: []Fault{{Probability: 1, DropResult: true}},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				return simple_orm.NewSelector[model.TestModel](db).GetMul(ctx)
+			},
+			wantVal: []*model.TestModel{},
+		},
+		{
+			name:   "drop exec result",
+			faults: []Fault{{Probability: 1, DropResult: true}},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				res, err := simple_orm.NewDeleter[model.TestModel](db).Exec(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return res.RowsAffected()
+			},
+			wantVal: int64(0),
+		},
+		{
+			name: "latency canceled",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				_ = cancel
+				return WithFaults(ctx, Fault{Probability: 1, Latency: time.Minute})
+			}(),
+			exec: func(db *simple_orm.DB, ctx context.Context) (any, error) {
+				return simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = mockDB.Close() }()
+			db, err := simple_orm.OpenDB(mockDB,
+				simple_orm.DBWithMiddleWare(NewChaosMiddleWare(tc.faults...).Build()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.mock != nil {
+				tc.mock(mock)
+			}
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			val, err := tc.exec(db, ctx)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
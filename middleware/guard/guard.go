@@ -0,0 +1,167 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"github.com/simple_orm"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrUnboundedWrite = errors.New("[guard] DELETE/UPDATE without WHERE")
+	ErrLimitTooLarge  = errors.New("[guard] LIMIT exceeds max limit")
+	ErrNoLimit        = errors.New("[guard] SELECT without LIMIT")
+	ErrNoIndex        = errors.New("[guard] WHERE uses no indexed column")
+)
+
+type allowUnboundedKey struct{}
+
+// AllowUnbounded 放行ctx中不带WHERE的DELETE/UPDATE，用于确实需要全表操作的场景
+func AllowUnbounded(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowUnboundedKey{}, true)
+}
+
+type GuardMiddleWare struct {
+	maxLimit     int  // SELECT允许的最大LIMIT，0表示不限制
+	defaultLimit int  // SELECT没有LIMIT时注入的默认值，0表示不注入
+	requireIndex bool // 模型声明了索引时，WHERE必须使用至少一个索引列
+}
+
+type GuardOption func(g *GuardMiddleWare)
+
+// WithMaxLimit SELECT的LIMIT不能超过max；未配置默认LIMIT时，不带LIMIT的SELECT会被拒绝
+func WithMaxLimit(max int) GuardOption {
+	return func(g *GuardMiddleWare) {
+		g.maxLimit = max
+	}
+}
+
+// WithDefaultLimit SELECT没有LIMIT时注入limit
+func WithDefaultLimit(limit int) GuardOption {
+	return func(g *GuardMiddleWare) {
+		g.defaultLimit = limit
+	}
+}
+
+// WithRequireIndex 拒绝WHERE中只使用了非索引列的查询，仅对声明了索引的模型生效
+func WithRequireIndex() GuardOption {
+	return func(g *GuardMiddleWare) {
+		g.requireIndex = true
+	}
+}
+
+func NewGuardMiddleWare(opts ...GuardOption) *GuardMiddleWare {
+	g := &GuardMiddleWare{}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+func (g *GuardMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			if err := g.check(ctx, qc); err != nil {
+				return &simple_orm.QueryResult{
+					Err: err,
+				}
+			}
+			return next(ctx, qc)
+		}
+	}
+}
+
+func (g *GuardMiddleWare) check(ctx context.Context, qc *simple_orm.QueryContext) error {
+	switch qc.Type {
+	case simple_orm.QueryTypeDelete, simple_orm.QueryTypeUpdate:
+		if len(qc.WhereColumns) == 0 {
+			if allow, _ := ctx.Value(allowUnboundedKey{}).(bool); !allow {
+				return ErrUnboundedWrite
+			}
+			return nil
+		}
+		return g.checkIndex(qc)
+	case simple_orm.QueryTypeSelect:
+		if err := g.checkLimit(qc); err != nil {
+			return err
+		}
+		return g.checkIndex(qc)
+	default:
+		// 原生查询与INSERT不做检查
+		return nil
+	}
+}
+
+// checkLimit COUNT(*)查询只返回一行，不需要LIMIT
+func (g *GuardMiddleWare) checkLimit(qc *simple_orm.QueryContext) error {
+	if qc.Count {
+		return nil
+	}
+	if qc.Limit == 0 {
+		if g.defaultLimit > 0 {
+			injectLimit(qc, g.defaultLimit)
+			return nil
+		}
+		if g.maxLimit > 0 {
+			return ErrNoLimit
+		}
+		return nil
+	}
+	if g.maxLimit > 0 && qc.Limit > g.maxLimit {
+		return ErrLimitTooLarge
+	}
+	return nil
+}
+
+func (g *GuardMiddleWare) checkIndex(qc *simple_orm.QueryContext) error {
+	if !g.requireIndex || qc.Model == nil || len(qc.WhereColumns) == 0 {
+		return nil
+	}
+	declared := false
+	for _, field := range qc.Model.Col2Field {
+		if field.Index {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		return nil
+	}
+	for _, col := range qc.WhereColumns {
+		if field, ok := qc.Model.Col2Field[col]; ok && field.Index {
+			return nil
+		}
+	}
+	return ErrNoIndex
+}
+
+// lockSuffix 行锁子句位于LIMIT/OFFSET之后
+var lockSuffix = regexp.MustCompile(` FOR (UPDATE|SHARE)( NOWAIT| SKIP LOCKED)?$`)
+
+// injectLimit 在SELECT中注入LIMIT，LIMIT需要位于OFFSET与行锁子句之前
+func injectLimit(qc *simple_orm.QueryContext, limit int) {
+	q := qc.Query
+	sql := strings.TrimSuffix(q.SQL, ";")
+	lock := lockSuffix.FindString(sql)
+	sql = strings.TrimSuffix(sql, lock)
+	// 不在原切片上修改，避免影响builder
+	args := append([]any(nil), q.Args...)
+	argCols := append([]string(nil), qc.ArgColumns...)
+	if strings.HasSuffix(sql, " OFFSET ?") {
+		// OFFSET的参数是最后一个
+		q.SQL = strings.TrimSuffix(sql, " OFFSET ?") + " LIMIT ? OFFSET ?" + lock + ";"
+		q.Args = append(args[:len(args)-1], limit, args[len(args)-1])
+		if len(argCols) == len(args) {
+			argCols = append(argCols[:len(argCols)-1], "", "")
+		}
+	} else {
+		q.SQL = sql + " LIMIT ?" + lock + ";"
+		q.Args = append(args, limit)
+		if len(argCols) == len(args) {
+			argCols = append(argCols, "")
+		}
+	}
+	qc.ArgColumns = argCols
+	qc.Limit = limit
+}
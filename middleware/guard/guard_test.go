@@ -0,0 +1,226 @@
+package guard
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type Order struct {
+	Id     int64 `orm:"index"`
+	UserId int64 `orm:"index"`
+	Remark string
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []GuardOption
+		ctx      context.Context
+		exec     func(db *simple_orm.DB, ctx context.Context) error
+		wantSQL  string
+		wantArgs []any
+		wantErr  error
+	}{
+		{
+			name: "delete without where",
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewDeleter[Order](db).Exec(ctx)
+				return err
+			},
+			wantErr: ErrUnboundedWrite,
+		},
+		{
+			name: "delete without where allowed",
+			ctx:  AllowUnbounded(context.Background()),
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewDeleter[Order](db).Exec(ctx)
+				return err
+			},
+			wantSQL: "DELETE FROM `order`;",
+		},
+		{
+			name: "delete with where",
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewDeleter[Order](db).Where(simple_orm.NewColumn("Id").EQ(1)).Exec(ctx)
+				return err
+			},
+			wantSQL:  "DELETE FROM `order` WHERE `id` = ?;",
+			wantArgs: []any{1},
+		},
+		{
+			name: "limit too large",
+			opts: []GuardOption{WithMaxLimit(100)},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[Order](db).Limit(1000).GetMul(ctx)
+				return err
+			},
+			wantErr: ErrLimitTooLarge,
+		},
+		{
+			name: "no limit",
+			opts: []GuardOption{WithMaxLimit(100)},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[Order](db).GetMul(ctx)
+				return err
+			},
+			wantErr: ErrNoLimit,
+		},
+		{
+			name: "inject default limit",
+			opts: []GuardOption{WithMaxLimit(100), WithDefaultLimit(20)},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[Order](db).GetMul(ctx)
+				return err
+			},
+			wantSQL:  "SELECT * FROM `order` LIMIT ?;",
+			wantArgs: []any{20},
+		},
+		{
+			name: "inject default limit before offset",
+			opts: []GuardOption{WithDefaultLimit(20)},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[Order](db).Where(simple_orm.NewColumn("UserId").EQ(3)).
+					Offset(40).GetMul(ctx)
+				return err
+			},
+			wantSQL:  "SELECT * FROM `order` WHERE `user_id` = ? LIMIT ? OFFSET ?;",
+			wantArgs: []any{3, 20, 40},
+		},
+		{
+			name: "no index used",
+			opts: []GuardOption{WithRequireIndex()},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[Order](db).Where(simple_orm.NewColumn("Remark").EQ("a")).GetMul(ctx)
+				return err
+			},
+			wantErr: ErrNoIndex,
+		},
+		{
+			name: "index used",
+			opts: []GuardOption{WithRequireIndex()},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[Order](db).
+					Where(simple_orm.NewColumn("Remark").EQ("a"), simple_orm.NewColumn("UserId").EQ(3)).GetMul(ctx)
+				return err
+			},
+			wantSQL:  "SELECT * FROM `order` WHERE (`remark` = ?) AND (`user_id` = ?);",
+			wantArgs: []any{"a", 3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = mockDB.Close() }()
+			db, err := simple_orm.OpenDB(mockDB,
+				simple_orm.DBWithMiddleWare(NewGuardMiddleWare(tc.opts...).Build()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantSQL != "" {
+				if tc.wantSQL[0] == 'S' {
+					mock.ExpectQuery(tc.wantSQL).WithArgs(toDriverArgs(tc.wantArgs)...).
+						WillReturnRows(sqlmock.NewRows([]string{"Id"}))
+				} else {
+					mock.ExpectExec(tc.wantSQL).WithArgs(toDriverArgs(tc.wantArgs)...).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			err = tc.exec(db, ctx)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMiddlewareBuilder_Page(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []GuardOption
+	}{
+		{
+			name: "max limit",
+			opts: []GuardOption{WithMaxLimit(100)},
+		},
+		{
+			name: "default limit",
+			opts: []GuardOption{WithDefaultLimit(20)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = mockDB.Close() }()
+			db, err := simple_orm.OpenDB(mockDB,
+				simple_orm.DBWithMiddleWare(NewGuardMiddleWare(tc.opts...).Build()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// COUNT查询不检查也不注入LIMIT
+			mock.ExpectQuery("SELECT COUNT(*) FROM `order`;").
+				WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(25))
+			mock.ExpectQuery("SELECT * FROM `order` LIMIT ? OFFSET ?;").WithArgs(int64(10), int64(10)).
+				WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(11))
+			page, err := simple_orm.NewSelector[Order](db).Page(context.Background(), 2, 10)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(25), page.Total)
+			assert.Len(t, page.Items, 1)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// toDriverArgs database/sql会将int转换为int64
+func toDriverArgs(args []any) []driver.Value {
+	res := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		if n, ok := arg.(int); ok {
+			arg = int64(n)
+		}
+		res = append(res, arg)
+	}
+	return res
+}
+
+func TestInjectLimit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    *simple_orm.Query
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "lock",
+			query:    &simple_orm.Query{SQL: "SELECT * FROM `order` FOR UPDATE;"},
+			wantSQL:  "SELECT * FROM `order` LIMIT ? FOR UPDATE;",
+			wantArgs: []any{20},
+		},
+		{
+			name:     "offset and lock",
+			query:    &simple_orm.Query{SQL: "SELECT * FROM `order` OFFSET ? FOR SHARE SKIP LOCKED;", Args: []any{40}},
+			wantSQL:  "SELECT * FROM `order` LIMIT ? OFFSET ? FOR SHARE SKIP LOCKED;",
+			wantArgs: []any{20, 40},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			qc := &simple_orm.QueryContext{Query: tc.query}
+			injectLimit(qc, 20)
+			assert.Equal(t, tc.wantSQL, qc.Query.SQL)
+			assert.Equal(t, tc.wantArgs, qc.Query.Args)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package callsite
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// sourceDir ORM源码所在目录，查找调用方时跳过该目录下的非测试文件
+var sourceDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	// file为 <module>/middleware/internal/callsite/callsite.go
+	dir := filepath.Dir(file)
+	for i := 0; i < 3; i++ {
+		dir = filepath.Dir(dir)
+	}
+	return filepath.ToSlash(dir) + "/"
+}()
+
+// Caller 返回第一个不属于ORM源码的调用栈位置，格式为file:line
+func Caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		file := filepath.ToSlash(frame.File)
+		if (!strings.HasPrefix(file, sourceDir) || strings.HasSuffix(file, "_test.go")) &&
+			!strings.HasPrefix(frame.Function, "runtime.") {
+			return file + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
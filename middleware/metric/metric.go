@@ -0,0 +1,42 @@
+package metric
+
+import (
+	"context"
+	"github.com/simple_orm"
+	"time"
+)
+
+type MetricMiddleWare struct {
+	recorder Recorder
+}
+
+func NewMetricMiddleWare(recorder Recorder) *MetricMiddleWare {
+	return &MetricMiddleWare{
+		recorder: recorder,
+	}
+}
+
+func (m *MetricMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			labels := Labels{
+				Operation: qc.Type,
+				Dialect:   qc.Dialect,
+			}
+			if qc.Model != nil {
+				labels.Table = qc.Model.TableName
+			}
+			start := time.Now()
+			res := next(ctx, qc)
+			m.recorder.ObserveLatency(labels, time.Since(start))
+			m.recorder.IncQuery(labels)
+			if qc.Attempt > 0 {
+				m.recorder.IncRetry(labels)
+			}
+			if res.Err != nil {
+				m.recorder.IncError(labels)
+			}
+			return res
+		}
+	}
+}
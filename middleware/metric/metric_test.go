@@ -0,0 +1,59 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/simple_orm"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	recorder := NewMemoryRecorder()
+	db, err := simple_orm.OpenDB(mockDB,
+		simple_orm.DBWithMiddleWare(NewMetricMiddleWare(recorder).Build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	mock.ExpectQuery("SELECT .*").WillReturnError(errors.New("mock error"))
+	mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = simple_orm.NewSelector[model.TestModel](db).Get(context.Background())
+	assert.Nil(t, err)
+	_, err = simple_orm.NewSelector[model.TestModel](db).Get(context.Background())
+	assert.Equal(t, errors.New("mock error"), errors.Unwrap(err))
+	_, err = simple_orm.NewDeleter[model.TestModel](db).Exec(context.Background())
+	assert.Nil(t, err)
+
+	selectStat := recorder.Stat(Labels{Operation: "SELECT", Table: "test_model", Dialect: "mysql"})
+	assert.Equal(t, int64(2), selectStat.Queries)
+	assert.Equal(t, int64(1), selectStat.Errors)
+	assert.Equal(t, 2, len(selectStat.Latencies))
+	deleteStat := recorder.Stat(Labels{Operation: "DELETE", Table: "test_model", Dialect: "mysql"})
+	assert.Equal(t, int64(1), deleteStat.Queries)
+	assert.Equal(t, int64(0), deleteStat.Errors)
+}
+
+func TestPrometheusRecorder(t *testing.T) {
+	recorder := NewPrometheusRecorder("test", nil)
+	reg := prometheus.NewRegistry()
+	assert.Nil(t, recorder.Register(reg))
+	labels := Labels{Operation: "SELECT", Table: "test_model", Dialect: "mysql"}
+	recorder.IncQuery(labels)
+	recorder.IncQuery(labels)
+	recorder.IncError(labels)
+	recorder.ObserveLatency(labels, 0)
+	assert.Equal(t, float64(2), testutil.ToFloat64(recorder.queries.WithLabelValues("SELECT", "test_model", "mysql")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(recorder.errors.WithLabelValues("SELECT", "test_model", "mysql")))
+	assert.Equal(t, 1, testutil.CollectAndCount(recorder.latency))
+}
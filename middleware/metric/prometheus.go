@@ -0,0 +1,76 @@
+package metric
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+var labelNames = []string{"operation", "table", "dialect"}
+
+// PrometheusRecorder 基于client_golang的Recorder
+type PrometheusRecorder struct {
+	queries *prometheus.CounterVec
+	errors  *prometheus.CounterVec
+	retries *prometheus.CounterVec
+	latency *prometheus.HistogramVec
+}
+
+// NewPrometheusRecorder buckets为空时使用prometheus.DefBuckets，单位是秒
+func NewPrometheusRecorder(namespace string, buckets []float64) *PrometheusRecorder {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	return &PrometheusRecorder{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "orm",
+			Name:      "queries_total",
+			Help:      "Total number of queries.",
+		}, labelNames),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "orm",
+			Name:      "query_errors_total",
+			Help:      "Total number of failed queries.",
+		}, labelNames),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "orm",
+			Name:      "query_retries_total",
+			Help:      "Total number of retried queries.",
+		}, labelNames),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "orm",
+			Name:      "query_duration_seconds",
+			Help:      "Query latency in seconds.",
+			Buckets:   buckets,
+		}, labelNames),
+	}
+}
+
+// Register 将指标注册到registerer，通常是prometheus.DefaultRegisterer
+func (p *PrometheusRecorder) Register(registerer prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{p.queries, p.errors, p.retries, p.latency} {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PrometheusRecorder) IncQuery(labels Labels) {
+	p.queries.WithLabelValues(labels.Operation, labels.Table, labels.Dialect).Inc()
+}
+
+func (p *PrometheusRecorder) IncError(labels Labels) {
+	p.errors.WithLabelValues(labels.Operation, labels.Table, labels.Dialect).Inc()
+}
+
+func (p *PrometheusRecorder) IncRetry(labels Labels) {
+	p.retries.WithLabelValues(labels.Operation, labels.Table, labels.Dialect).Inc()
+}
+
+func (p *PrometheusRecorder) ObserveLatency(labels Labels, latency time.Duration) {
+	p.latency.WithLabelValues(labels.Operation, labels.Table, labels.Dialect).Observe(latency.Seconds())
+}
@@ -0,0 +1,93 @@
+package metric
+
+import (
+	"sync"
+	"time"
+)
+
+// Labels 指标的标签
+type Labels struct {
+	Operation string // 语句类型，SELECT/INSERT/DELETE/RAW
+	Table     string // 表名，原生查询且结果不是模型时为空
+	Dialect   string // 方言名称，如mysql
+}
+
+// Recorder 指标记录的抽象，可以对接Prometheus等监控系统
+type Recorder interface {
+	// IncQuery 查询次数+1
+	IncQuery(labels Labels)
+	// IncError 出错次数+1
+	IncError(labels Labels)
+	// IncRetry 重试次数+1，重试的查询同样计入IncQuery
+	IncRetry(labels Labels)
+	// ObserveLatency 记录一次查询的耗时
+	ObserveLatency(labels Labels, latency time.Duration)
+}
+
+// MemoryStat 内存中记录的单个标签组合的指标
+type MemoryStat struct {
+	Queries   int64
+	Errors    int64
+	Retries   int64
+	Latencies []time.Duration
+}
+
+// MemoryRecorder 基于内存的Recorder，用于单测
+type MemoryRecorder struct {
+	lock  sync.Mutex
+	stats map[Labels]*MemoryStat
+}
+
+func NewMemoryRecorder() *MemoryRecorder {
+	return &MemoryRecorder{
+		stats: map[Labels]*MemoryStat{},
+	}
+}
+
+func (m *MemoryRecorder) IncQuery(labels Labels) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.stat(labels).Queries++
+}
+
+func (m *MemoryRecorder) IncError(labels Labels) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.stat(labels).Errors++
+}
+
+func (m *MemoryRecorder) IncRetry(labels Labels) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.stat(labels).Retries++
+}
+
+func (m *MemoryRecorder) ObserveLatency(labels Labels, latency time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	stat := m.stat(labels)
+	stat.Latencies = append(stat.Latencies, latency)
+}
+
+// Stat 返回标签组合对应指标的拷贝，不存在时返回零值
+func (m *MemoryRecorder) Stat(labels Labels) MemoryStat {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	stat, ok := m.stats[labels]
+	if !ok {
+		return MemoryStat{}
+	}
+	res := *stat
+	res.Latencies = append([]time.Duration(nil), stat.Latencies...)
+	return res
+}
+
+// stat 调用方需持有锁
+func (m *MemoryRecorder) stat(labels Labels) *MemoryStat {
+	stat, ok := m.stats[labels]
+	if !ok {
+		stat = &MemoryStat{}
+		m.stats[labels] = stat
+	}
+	return stat
+}
@@ -0,0 +1,131 @@
+package n_plus_one
+
+import (
+	"context"
+	"fmt"
+	"github.com/simple_orm"
+	"github.com/simple_orm/middleware/internal/callsite"
+	"github.com/simple_orm/middleware/query_stats"
+	"strings"
+	"sync"
+)
+
+// Report 一次N+1检测结果
+type Report struct {
+	Fingerprint string
+	Count       int      // 不同参数执行的次数
+	CallSites   []string // 每次执行的调用位置，file:line
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("N+1 query detected, executed %d times with different args: %s\n\tcall sites:\n\t\t%s",
+		r.Count, r.Fingerprint, strings.Join(r.CallSites, "\n\t\t"))
+}
+
+// TestingT testing.TB的子集
+type TestingT interface {
+	Errorf(format string, args ...any)
+}
+
+// FailTest 发现N+1时让测试失败
+func FailTest(t TestingT) func(ctx context.Context, report Report) {
+	return func(ctx context.Context, report Report) {
+		t.Errorf("%s", report.String())
+	}
+}
+
+type scopeKey struct{}
+
+// scope 一次请求内的查询记录
+type scope struct {
+	lock       sync.Mutex
+	statements map[string]*statement
+}
+
+type statement struct {
+	args      map[string]struct{}
+	callSites []string
+	reported  bool
+}
+
+// WithScope 开启一次检测范围，通常是一次请求，只有范围内的查询会被检测
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{
+		statements: map[string]*statement{},
+	})
+}
+
+// DetectorMiddleWare 开发环境使用的N+1检测：同一范围内相同指纹的SQL以不同参数执行超过threshold次时上报
+type DetectorMiddleWare struct {
+	threshold int
+	reporter  func(ctx context.Context, report Report)
+}
+
+type DetectorOption func(d *DetectorMiddleWare)
+
+// WithThreshold 默认为5
+func WithThreshold(threshold int) DetectorOption {
+	return func(d *DetectorMiddleWare) {
+		d.threshold = threshold
+	}
+}
+
+// WithReporter 默认丢弃，测试中可以使用FailTest
+func WithReporter(reporter func(ctx context.Context, report Report)) DetectorOption {
+	return func(d *DetectorMiddleWare) {
+		d.reporter = reporter
+	}
+}
+
+func NewDetectorMiddleWare(opts ...DetectorOption) *DetectorMiddleWare {
+	d := &DetectorMiddleWare{
+		threshold: 5,
+		reporter:  func(ctx context.Context, report Report) {},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *DetectorMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			s, ok := ctx.Value(scopeKey{}).(*scope)
+			if !ok {
+				return next(ctx, qc)
+			}
+			if report, ok := d.track(s, qc); ok {
+				d.reporter(ctx, report)
+			}
+			return next(ctx, qc)
+		}
+	}
+}
+
+// track 记录查询，第一次超过阈值时返回report
+func (d *DetectorMiddleWare) track(s *scope, qc *simple_orm.QueryContext) (Report, bool) {
+	fingerprint := query_stats.Fingerprint(qc.Query.SQL)
+	args := fmt.Sprintf("%#v", qc.Query.Args)
+	site := callsite.Caller()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stmt, ok := s.statements[fingerprint]
+	if !ok {
+		stmt = &statement{
+			args: map[string]struct{}{},
+		}
+		s.statements[fingerprint] = stmt
+	}
+	stmt.args[args] = struct{}{}
+	stmt.callSites = append(stmt.callSites, site)
+	if stmt.reported || len(stmt.args) <= d.threshold {
+		return Report{}, false
+	}
+	stmt.reported = true
+	return Report{
+		Fingerprint: fingerprint,
+		Count:       len(stmt.args),
+		CallSites:   append([]string(nil), stmt.callSites...),
+	}, true
+}
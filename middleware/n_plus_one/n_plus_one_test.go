@@ -0,0 +1,82 @@
+package n_plus_one
+
+import (
+	"context"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type mockT struct {
+	errs []string
+}
+
+func (m *mockT) Errorf(format string, args ...any) {
+	m.errs = append(m.errs, fmt.Sprintf(format, args...))
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name       string
+		scoped     bool
+		ids        []int
+		wantReport bool
+	}{
+		{
+			name:       "n plus one",
+			scoped:     true,
+			ids:        []int{1, 2, 3, 4},
+			wantReport: true,
+		},
+		{
+			name:   "same args",
+			scoped: true,
+			ids:    []int{1, 1, 1, 1},
+		},
+		{
+			name:   "below threshold",
+			scoped: true,
+			ids:    []int{1, 2, 3},
+		},
+		{
+			name: "no scope",
+			ids:  []int{1, 2, 3, 4},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = mockDB.Close() }()
+			mt := &mockT{}
+			db, err := simple_orm.OpenDB(mockDB, simple_orm.DBWithMiddleWare(
+				NewDetectorMiddleWare(WithThreshold(3), WithReporter(FailTest(mt))).Build()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if tc.scoped {
+				ctx = WithScope(ctx)
+			}
+			for _, id := range tc.ids {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(id))
+				_, err = simple_orm.NewSelector[model.TestModel](db).Where(simple_orm.NewColumn("Id").EQ(id)).Get(ctx)
+				assert.Nil(t, err)
+			}
+			assert.Nil(t, mock.ExpectationsWereMet())
+			if !tc.wantReport {
+				assert.Equal(t, 0, len(mt.errs))
+				return
+			}
+			assert.Equal(t, 1, len(mt.errs))
+			assert.True(t, strings.Contains(mt.errs[0], "SELECT * FROM `test_model` WHERE `id` = ?;"), mt.errs[0])
+			assert.Equal(t, 4, strings.Count(mt.errs[0], "n_plus_one_test.go:"), mt.errs[0])
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package query_stats
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	inListRegexp = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?(\s*,\s*\?)*\s*\)`)
+	// VALUES(?,?),(?,?) 多行插入合并为一行
+	valuesRegexp = regexp.MustCompile(`(\(\?(?:,\s*\?)*\))(?:\s*,\s*\(\?(?:,\s*\?)*\))+`)
+)
+
+// Fingerprint 将SQL归一化：去掉注释，字面量替换为?，IN列表与多行VALUES合并，连续空白合并为一个空格。
+// 只是参数不同的SQL得到相同的指纹
+func Fingerprint(sql string) string {
+	var sb strings.Builder
+	sb.Grow(len(sql))
+	space := false
+	writeSpace := func() {
+		if space && sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		space = false
+	}
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*': // 注释，如sqlcommenter
+			end := strings.Index(sql[i+2:], "*/")
+			if end == -1 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+			space = true
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end == -1 {
+				i = len(sql)
+			} else {
+				i += end
+			}
+			space = true
+		case c == '`' || c == '"': // 标识符原样保留
+			writeSpace()
+			end := strings.IndexByte(sql[i+1:], c)
+			if end == -1 {
+				end = len(sql) - i - 1
+			} else {
+				end++
+			}
+			sb.WriteString(sql[i : i+end+1])
+			i += end + 1
+		case c == '\'': // 字符串字面量
+			writeSpace()
+			i = skipString(sql, i)
+			sb.WriteByte('?')
+		case c == '$' && i+1 < len(sql) && isDigit(sql[i+1]): // PostgreSQL占位符
+			writeSpace()
+			i++
+			for i < len(sql) && isDigit(sql[i]) {
+				i++
+			}
+			sb.WriteByte('?')
+		case isDigit(c) && (i == 0 || !isIdent(sql[i-1])): // 数字字面量
+			writeSpace()
+			for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.' || sql[i] == 'e' || sql[i] == 'E') {
+				i++
+			}
+			sb.WriteByte('?')
+		default:
+			writeSpace()
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	res := inListRegexp.ReplaceAllString(sb.String(), "IN (...)")
+	return valuesRegexp.ReplaceAllString(res, "$1")
+}
+
+// skipString 返回字符串字面量结束后的下标，支持 '' 与 \' 转义
+func skipString(sql string, start int) int {
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			i++
+		case '\'':
+			if i+1 < len(sql) && sql[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c)
+}
@@ -0,0 +1,171 @@
+package query_stats
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/simple_orm"
+	"math"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 每个指纹保留的耗时样本数，超过后使用蓄水池采样
+const maxSamples = 1024
+
+// StatementStats 单个指纹的统计
+type StatementStats struct {
+	Fingerprint  string        `json:"fingerprint"`
+	Operation    string        `json:"operation"`
+	Table        string        `json:"table,omitempty"`
+	Calls        int64         `json:"calls"`
+	Errors       int64         `json:"errors"`
+	Rows         int64         `json:"rows"` // SELECT返回的行数或写操作影响的行数之和
+	TotalLatency time.Duration `json:"total_latency"`
+	MinLatency   time.Duration `json:"min_latency"`
+	MaxLatency   time.Duration `json:"max_latency"`
+	P99Latency   time.Duration `json:"p99_latency"`
+}
+
+type statement struct {
+	stats   StatementStats
+	samples []time.Duration
+}
+
+// StatsMiddleWare 按指纹聚合查询的统计信息，类似于pg_stat_statements
+type StatsMiddleWare struct {
+	lock       sync.Mutex
+	statements map[string]*statement
+	rand       *rand.Rand
+}
+
+func NewStatsMiddleWare() *StatsMiddleWare {
+	return &StatsMiddleWare{
+		statements: map[string]*statement{},
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (s *StatsMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			fingerprint := Fingerprint(qc.Query.SQL)
+			start := time.Now()
+			res := next(ctx, qc)
+			s.record(fingerprint, qc, res, time.Since(start))
+			return res
+		}
+	}
+}
+
+func (s *StatsMiddleWare) record(fingerprint string, qc *simple_orm.QueryContext,
+	res *simple_orm.QueryResult, latency time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stmt, ok := s.statements[fingerprint]
+	if !ok {
+		stmt = &statement{
+			stats: StatementStats{
+				Fingerprint: fingerprint,
+				Operation:   qc.Type,
+				MinLatency:  latency,
+			},
+		}
+		if qc.Model != nil {
+			stmt.stats.Table = qc.Model.TableName
+		}
+		s.statements[fingerprint] = stmt
+	}
+	stats := &stmt.stats
+	stats.Calls++
+	if res.Err != nil {
+		stats.Errors++
+	} else {
+		stats.Rows += rowsOf(qc, res)
+	}
+	stats.TotalLatency += latency
+	if latency < stats.MinLatency {
+		stats.MinLatency = latency
+	}
+	if latency > stats.MaxLatency {
+		stats.MaxLatency = latency
+	}
+	if len(stmt.samples) < maxSamples {
+		stmt.samples = append(stmt.samples, latency)
+	} else if idx := s.rand.Int63n(stats.Calls); idx < maxSamples {
+		stmt.samples[idx] = latency
+	}
+}
+
+// rowsOf SELECT返回行数，写操作返回影响行数，流式查询在执行时无法得知行数
+func rowsOf(qc *simple_orm.QueryContext, res *simple_orm.QueryResult) int64 {
+	if qc.Stream {
+		return 0
+	}
+	switch qc.Type {
+	case simple_orm.QueryTypeSelect, simple_orm.QueryTypeRaw:
+		if !qc.Multi {
+			return 1
+		}
+		v := reflect.ValueOf(res.Result)
+		if v.Kind() == reflect.Slice {
+			return int64(v.Len())
+		}
+		return 0
+	default:
+		return res.RowsAffected
+	}
+}
+
+// Stats 所有指纹的统计，按总耗时降序
+func (s *StatsMiddleWare) Stats() []StatementStats {
+	s.lock.Lock()
+	res := make([]StatementStats, 0, len(s.statements))
+	for _, stmt := range s.statements {
+		stats := stmt.stats
+		stats.P99Latency = percentile(stmt.samples, 0.99)
+		res = append(res, stats)
+	}
+	s.lock.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].TotalLatency != res[j].TotalLatency {
+			return res[i].TotalLatency > res[j].TotalLatency
+		}
+		return res[i].Fingerprint < res[j].Fingerprint
+	})
+	return res
+}
+
+// Reset 清空统计
+func (s *StatsMiddleWare) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.statements = map[string]*statement{}
+}
+
+// ServeHTTP 以JSON输出统计，可以直接挂载到调试路由上
+func (s *StatsMiddleWare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Stats()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	// nearest-rank
+	idx := int(math.Ceil(float64(len(sorted))*p)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
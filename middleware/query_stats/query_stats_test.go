@@ -0,0 +1,110 @@
+package query_stats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	testCases := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "placeholder",
+			sql:  "SELECT * FROM `test_model` WHERE `id` = ?;",
+			want: "SELECT * FROM `test_model` WHERE `id` = ?;",
+		},
+		{
+			name: "literals",
+			sql:  "SELECT * FROM `t1` WHERE `name` = 'it''s' AND age > 18.5 AND `c2` = 'a\\'b'",
+			want: "SELECT * FROM `t1` WHERE `name` = ? AND age > ? AND `c2` = ?",
+		},
+		{
+			name: "in list",
+			sql:  "SELECT * FROM t WHERE id IN (1, 2, 3) OR id in (?,?)",
+			want: "SELECT * FROM t WHERE id IN (...) OR id IN (...)",
+		},
+		{
+			name: "values",
+			sql:  "INSERT INTO `t`(`a`,`b`) VALUES(?,?),(?,?),(?,?);",
+			want: "INSERT INTO `t`(`a`,`b`) VALUES(?,?);",
+		},
+		{
+			name: "comment and whitespace",
+			sql:  "SELECT *\n  FROM t  /*traceparent='00-01'*/ WHERE id = $1 -- tail",
+			want: "SELECT * FROM t WHERE id = ?",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Fingerprint(tc.sql))
+		})
+	}
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	s := NewStatsMiddleWare()
+	db, err := simple_orm.OpenDB(mockDB, simple_orm.DBWithMiddleWare(s.Build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1).AddRow(2))
+	mock.ExpectQuery("SELECT .*").WillReturnError(errors.New("mock error"))
+	mock.ExpectExec("DELETE .*").WillDelayFor(10 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 4))
+	_, err = simple_orm.NewSelector[model.TestModel](db).Where(simple_orm.NewColumn("Id").GT(0)).GetMul(ctx)
+	assert.Nil(t, err)
+	_, err = simple_orm.NewSelector[model.TestModel](db).Where(simple_orm.NewColumn("Id").GT(10)).GetMul(ctx)
+	assert.NotNil(t, err)
+	_, err = simple_orm.NewDeleter[model.TestModel](db).Exec(ctx)
+	assert.Nil(t, err)
+
+	stats := s.Stats()
+	assert.Equal(t, 2, len(stats))
+	// 按总耗时降序
+	assert.Equal(t, "DELETE FROM `test_model`;", stats[0].Fingerprint)
+	assert.Equal(t, int64(4), stats[0].Rows)
+	assert.Equal(t, "SELECT * FROM `test_model` WHERE `id` > ?;", stats[1].Fingerprint)
+	assert.Equal(t, "test_model", stats[1].Table)
+	assert.Equal(t, int64(2), stats[1].Calls)
+	assert.Equal(t, int64(1), stats[1].Errors)
+	assert.Equal(t, int64(2), stats[1].Rows)
+	assert.True(t, stats[1].MinLatency <= stats[1].MaxLatency)
+	assert.Equal(t, stats[1].MaxLatency, stats[1].P99Latency)
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/orm/stats", nil))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var got []StatementStats
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	assert.Equal(t, stats, got)
+
+	s.Reset()
+	assert.Equal(t, 0, len(s.Stats()))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPercentile(t *testing.T) {
+	samples := make([]time.Duration, 0, 100)
+	for i := 100; i > 0; i-- {
+		samples = append(samples, time.Duration(i))
+	}
+	assert.Equal(t, time.Duration(99), percentile(samples, 0.99))
+	assert.Equal(t, time.Duration(0), percentile(nil, 0.99))
+}
@@ -0,0 +1,143 @@
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/simple_orm"
+	"math/rand"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type idempotentKey struct{}
+
+// Idempotent 标记ctx中的写操作是幂等的，允许重试
+func Idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// RetryMiddleWare 对瞬时错误进行重试，仅重试SELECT与标记为幂等的语句，事务中不重试。
+// 需要注册在metric、slow_log等中间件之前（外层），这样每次重试都会经过这些中间件
+type RetryMiddleWare struct {
+	maxRetries  int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	isTransient func(err error) bool
+	random      func() float64
+}
+
+type RetryOption func(r *RetryMiddleWare)
+
+// WithMaxRetries 最大重试次数，默认3次
+func WithMaxRetries(max int) RetryOption {
+	return func(r *RetryMiddleWare) {
+		r.maxRetries = max
+	}
+}
+
+// WithBackoff 第n次重试等待[0, min(max, base*2^n))之间的随机时间，默认base为10ms，max为1s
+func WithBackoff(base time.Duration, max time.Duration) RetryOption {
+	return func(r *RetryMiddleWare) {
+		r.baseBackoff = base
+		r.maxBackoff = max
+	}
+}
+
+// WithTransientFunc 自定义哪些错误可以重试
+func WithTransientFunc(fn func(err error) bool) RetryOption {
+	return func(r *RetryMiddleWare) {
+		r.isTransient = fn
+	}
+}
+
+func NewRetryMiddleWare(opts ...RetryOption) *RetryMiddleWare {
+	r := &RetryMiddleWare{
+		maxRetries:  3,
+		baseBackoff: 10 * time.Millisecond,
+		maxBackoff:  time.Second,
+		isTransient: IsTransient,
+		random:      rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *RetryMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			if !r.retryable(ctx, qc) {
+				return next(ctx, qc)
+			}
+			for attempt := 0; ; attempt++ {
+				// 下游中间件可能修改SQL，每次执行使用原始SQL的拷贝
+				attemptQC := *qc
+				query := *qc.Query
+				attemptQC.Query = &query
+				attemptQC.Attempt = attempt
+				res := next(ctx, &attemptQC)
+				if res.Err == nil || attempt >= r.maxRetries || !r.isTransient(res.Err) {
+					return res
+				}
+				if !r.wait(ctx, attempt) {
+					return res
+				}
+			}
+		}
+	}
+}
+
+func (r *RetryMiddleWare) retryable(ctx context.Context, qc *simple_orm.QueryContext) bool {
+	// 事务中的连接出错后事务已经无效，不能重试
+	if qc.TX != nil {
+		return false
+	}
+	if qc.Type == simple_orm.QueryTypeSelect {
+		return true
+	}
+	idempotent, _ := ctx.Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+// wait 等待退避时间，超过ctx的deadline或ctx结束时返回false
+func (r *RetryMiddleWare) wait(ctx context.Context, attempt int) bool {
+	backoff := r.baseBackoff << uint(attempt)
+	if backoff > r.maxBackoff || backoff <= 0 {
+		backoff = r.maxBackoff
+	}
+	backoff = time.Duration(r.random() * float64(backoff))
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+		return false
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// IsTransient 连接失效、连接被重置、死锁与锁等待超时等错误可以重试
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, simple_orm.ErrDeadlock) || errors.Is(err, simple_orm.ErrLockTimeout) {
+		return true
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	// 只匹配驱动的错误信息，避免SQL中的内容被误判
+	var qe *simple_orm.QueryError
+	if errors.As(err, &qe) {
+		err = qe.Err
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset") || strings.Contains(msg, "broken pipe")
+}
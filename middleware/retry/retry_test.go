@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm"
+	"github.com/simple_orm/middleware/metric"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	resetErr := errors.New("read tcp 127.0.0.1:3306: connection reset by peer")
+	testCases := []struct {
+		name        string
+		ctx         func() (context.Context, context.CancelFunc)
+		mock        func(mock sqlmock.Sqlmock)
+		exec        func(db *simple_orm.DB, ctx context.Context) error
+		wantErr     error
+		backoff     time.Duration
+		wantRetries int64
+		labels      metric.Labels
+	}{
+		{
+			name: "select retried",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnError(resetErr)
+				mock.ExpectQuery("SELECT .*").WillReturnError(resetErr)
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+				return err
+			},
+			wantRetries: 2,
+			labels:      metric.Labels{Operation: "SELECT", Table: "test_model", Dialect: "mysql"},
+		},
+		{
+			name: "max retries",
+			mock: func(mock sqlmock.Sqlmock) {
+				for i := 0; i < 4; i++ {
+					mock.ExpectQuery("SELECT .*").WillReturnError(resetErr)
+				}
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+				return err
+			},
+			wantErr:     resetErr,
+			wantRetries: 3,
+			labels:      metric.Labels{Operation: "SELECT", Table: "test_model", Dialect: "mysql"},
+		},
+		{
+			name: "not transient",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnError(errors.New("syntax error"))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+				return err
+			},
+			wantErr: errors.New("syntax error"),
+			labels:  metric.Labels{Operation: "SELECT", Table: "test_model", Dialect: "mysql"},
+		},
+		{
+			name: "delete not retried",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE .*").WillReturnError(resetErr)
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewDeleter[model.TestModel](db).Exec(ctx)
+				return err
+			},
+			wantErr: resetErr,
+			labels:  metric.Labels{Operation: "DELETE", Table: "test_model", Dialect: "mysql"},
+		},
+		{
+			name: "idempotent delete retried",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return Idempotent(context.Background()), func() {}
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE .*").WillReturnError(resetErr)
+				mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewDeleter[model.TestModel](db).Exec(ctx)
+				return err
+			},
+			wantRetries: 1,
+			labels:      metric.Labels{Operation: "DELETE", Table: "test_model", Dialect: "mysql"},
+		},
+		{
+			// 退避时间超过deadline，不再重试
+			name: "deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Second)
+			},
+			backoff: time.Minute,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnError(resetErr)
+			},
+			exec: func(db *simple_orm.DB, ctx context.Context) error {
+				_, err := simple_orm.NewSelector[model.TestModel](db).Get(ctx)
+				return err
+			},
+			wantErr: resetErr,
+			labels:  metric.Labels{Operation: "SELECT", Table: "test_model", Dialect: "mysql"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = mockDB.Close() }()
+			recorder := metric.NewMemoryRecorder()
+			backoff := tc.backoff
+			if backoff == 0 {
+				backoff = time.Millisecond
+			}
+			r := NewRetryMiddleWare(WithBackoff(backoff, backoff))
+			// 退避时间固定为上限，便于测试deadline
+			r.random = func() float64 { return 1 }
+			db, err := simple_orm.OpenDB(mockDB,
+				simple_orm.DBWithNamedMiddleWare("metric", metric.NewMetricMiddleWare(recorder).Build()),
+				simple_orm.DBWithNamedMiddleWare("retry", r.Build(), simple_orm.MiddleWareBefore("metric")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.mock(mock)
+			ctx, cancel := context.Background(), func() {}
+			if tc.ctx != nil {
+				ctx, cancel = tc.ctx()
+			}
+			defer cancel()
+			err = tc.exec(db, ctx)
+			assert.Equal(t, tc.wantErr, errors.Unwrap(err))
+			assert.Equal(t, tc.wantRetries, recorder.Stat(tc.labels).Retries)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.False(t, IsTransient(nil))
+	assert.True(t, IsTransient(errors.New("write: broken pipe")))
+	assert.False(t, IsTransient(errors.New("Duplicate entry")))
+	assert.True(t, IsTransient(&simple_orm.QueryError{Kind: simple_orm.ErrDeadlock, Err: errors.New("deadlock")}))
+	assert.False(t, IsTransient(&simple_orm.QueryError{Kind: simple_orm.ErrDuplicateKey, Err: errors.New("duplicate")}))
+	assert.True(t, IsTransient(&simple_orm.QueryError{SQL: "SELECT 1;", Err: errors.New("connection reset by peer")}))
+	// SQL中的内容不参与匹配
+	assert.False(t, IsTransient(&simple_orm.QueryError{SQL: "SELECT 'connection reset';", Err: errors.New("syntax error")}))
+}
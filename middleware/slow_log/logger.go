@@ -0,0 +1,67 @@
+package slow_log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Entry 一条查询日志
+type Entry struct {
+	Operation    string        `json:"operation"`
+	Table        string        `json:"table,omitempty"`
+	SQL          string        `json:"sql"`
+	Args         []any         `json:"args,omitempty"`
+	Duration     time.Duration `json:"duration"`
+	Slow         bool          `json:"slow"`
+	RowsAffected int64         `json:"rows_affected,omitempty"`
+	Attempt      int           `json:"attempt,omitempty"` // 重试次数，首次执行为0
+	Err          error         `json:"-"`
+	Caller       string        `json:"caller,omitempty"` // 业务代码中发起查询的位置，file:line
+}
+
+// Logger 日志输出的抽象，可以对接zap、logrus等日志库
+type Logger interface {
+	Log(ctx context.Context, entry *Entry)
+}
+
+// JSONLogger 每条日志输出为一行JSON
+type JSONLogger struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{
+		w: w,
+	}
+}
+
+func (j *JSONLogger) Log(ctx context.Context, entry *Entry) {
+	// Err无法直接序列化，转换成字符串输出
+	data, err := json.Marshal(struct {
+		*Entry
+		Duration string `json:"duration"`
+		Err      string `json:"error,omitempty"`
+	}{
+		Entry:    entry,
+		Duration: entry.Duration.String(),
+		Err:      errString(entry.Err),
+	})
+	if err != nil {
+		data = []byte(fmt.Sprintf(`{"sql":%q,"error":%q}`, entry.SQL, err.Error()))
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	_, _ = j.w.Write(append(data, '\n'))
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,95 @@
+package slow_log
+
+import (
+	"context"
+	"github.com/simple_orm"
+	"github.com/simple_orm/middleware/internal/callsite"
+	"math/rand"
+	"time"
+)
+
+const redacted = "***"
+
+type SlowLogMiddleWare struct {
+	logger     Logger
+	threshold  time.Duration  // 耗时大于等于threshold的查询视为慢查询
+	sampleRate float64        // 非慢查询的采样率，0表示只记录慢查询与出错的查询
+	random     func() float64 // 采样使用的随机数，[0, 1)
+}
+
+type SlowLogOption func(s *SlowLogMiddleWare)
+
+// WithThreshold 慢查询阈值，默认200ms
+func WithThreshold(threshold time.Duration) SlowLogOption {
+	return func(s *SlowLogMiddleWare) {
+		s.threshold = threshold
+	}
+}
+
+// WithSampleRate 非慢查询按比例记录，取值[0, 1]
+func WithSampleRate(rate float64) SlowLogOption {
+	return func(s *SlowLogMiddleWare) {
+		s.sampleRate = rate
+	}
+}
+
+func NewSlowLogMiddleWare(logger Logger, opts ...SlowLogOption) *SlowLogMiddleWare {
+	s := &SlowLogMiddleWare{
+		logger:    logger,
+		threshold: 200 * time.Millisecond,
+		random:    rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *SlowLogMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			start := time.Now()
+			res := next(ctx, qc)
+			duration := time.Since(start)
+			slow := duration >= s.threshold
+			// 慢查询与出错的查询总是记录
+			if !slow && res.Err == nil && (s.sampleRate <= 0 || s.random() >= s.sampleRate) {
+				return res
+			}
+			entry := &Entry{
+				Operation:    qc.Type,
+				SQL:          qc.Query.SQL,
+				Args:         redactArgs(qc),
+				Duration:     duration,
+				Slow:         slow,
+				RowsAffected: res.RowsAffected,
+				Attempt:      qc.Attempt,
+				Err:          res.Err,
+				Caller:       callsite.Caller(),
+			}
+			if qc.Model != nil {
+				entry.Table = qc.Model.TableName
+			}
+			s.logger.Log(ctx, entry)
+			return res
+		}
+	}
+}
+
+// redactArgs 将敏感列对应的参数替换为***
+func redactArgs(qc *simple_orm.QueryContext) []any {
+	args := make([]any, len(qc.Query.Args))
+	copy(args, qc.Query.Args)
+	if qc.Model == nil {
+		return args
+	}
+	for i, col := range qc.ArgColumns {
+		if i >= len(args) || col == "" {
+			continue
+		}
+		if field, ok := qc.Model.Col2Field[col]; ok && field.Sensitive {
+			args[i] = redacted
+		}
+	}
+	return args
+}
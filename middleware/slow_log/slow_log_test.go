@@ -0,0 +1,145 @@
+package slow_log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+type User struct {
+	Id    int64
+	Phone string `orm:"sensitive"`
+}
+
+type memoryLogger struct {
+	entries []*Entry
+}
+
+func (m *memoryLogger) Log(ctx context.Context, entry *Entry) {
+	m.entries = append(m.entries, entry)
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name        string
+		opts        []SlowLogOption
+		mockErr     error
+		wantLogged  bool
+		wantSlow    bool
+		wantArgs    []any
+		wantErrText string
+	}{
+		{
+			name:       "fast query not logged",
+			opts:       []SlowLogOption{WithThreshold(time.Hour)},
+			wantLogged: false,
+		},
+		{
+			name:       "slow query",
+			opts:       []SlowLogOption{WithThreshold(0)},
+			wantLogged: true,
+			wantSlow:   true,
+			wantArgs:   []any{int64(1), redacted},
+		},
+		{
+			name:       "sampled",
+			opts:       []SlowLogOption{WithThreshold(time.Hour), WithSampleRate(1)},
+			wantLogged: true,
+			wantArgs:   []any{int64(1), redacted},
+		},
+		{
+			name:        "error",
+			opts:        []SlowLogOption{WithThreshold(time.Hour)},
+			mockErr:     errors.New("mock error"),
+			wantLogged:  true,
+			wantArgs:    []any{int64(1), redacted},
+			wantErrText: "mock error, sql: INSERT INTO `user`(`id`,`phone`) VALUES(?,?);",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = mockDB.Close() }()
+			logger := &memoryLogger{}
+			db, err := simple_orm.OpenDB(mockDB,
+				simple_orm.DBWithMiddleWare(NewSlowLogMiddleWare(logger, tc.opts...).Build()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			exp := mock.ExpectExec("INSERT .*")
+			if tc.mockErr != nil {
+				exp.WillReturnError(tc.mockErr)
+			} else {
+				exp.WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+			_, _ = simple_orm.NewInserter[User](db).Values(&User{Id: 1, Phone: "13800000000"}).Exec(context.Background())
+			if !tc.wantLogged {
+				assert.Equal(t, 0, len(logger.entries))
+				return
+			}
+			assert.Equal(t, 1, len(logger.entries))
+			entry := logger.entries[0]
+			assert.Equal(t, "INSERT", entry.Operation)
+			assert.Equal(t, "user", entry.Table)
+			assert.Equal(t, tc.wantSlow, entry.Slow)
+			assert.Equal(t, tc.wantArgs, entry.Args)
+			assert.Equal(t, tc.wantErrText, errString(entry.Err))
+			assert.True(t, strings.Contains(entry.Caller, "slow_log_test.go:"), entry.Caller)
+		})
+	}
+}
+
+func TestMiddlewareBuilder_RedactSeek(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	logger := &memoryLogger{}
+	db, err := simple_orm.OpenDB(mockDB,
+		simple_orm.DBWithMiddleWare(NewSlowLogMiddleWare(logger, WithThreshold(0)).Build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 游标中的敏感列同样脱敏，无论使用行值还是展开的OR条件
+	orderBys := [][]*simple_orm.OrderBy{
+		{simple_orm.Asc("Phone"), simple_orm.Asc("Id")},
+		{simple_orm.Asc("Phone"), simple_orm.Desc("Id")},
+	}
+	for _, orderBy := range orderBys {
+		cursor, err := simple_orm.NextCursor[User](db, &User{Id: 1, Phone: "13800000000"}, orderBy...)
+		assert.Nil(t, err)
+		mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}))
+		_, err = simple_orm.NewSelector[User](db).OrderBy(orderBy...).Paginate(context.Background(), cursor, 2)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, len(logger.entries))
+	assert.Equal(t, []any{redacted, int64(1), 3}, logger.entries[0].Args)
+	assert.Equal(t, []any{redacted, redacted, int64(1), 3}, logger.entries[1].Args)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestJSONLogger_Log(t *testing.T) {
+	buf := &bytes.Buffer{}
+	NewJSONLogger(buf).Log(context.Background(), &Entry{
+		Operation: "SELECT",
+		Table:     "user",
+		SQL:       "SELECT * FROM `user` WHERE `phone` = ?;",
+		Args:      []any{redacted},
+		Duration:  time.Second,
+		Slow:      true,
+		Err:       errors.New("mock error"),
+		Caller:    "user.go:10",
+	})
+	assert.Equal(t, `{"operation":"SELECT","table":"user","sql":"SELECT * FROM `+"`user`"+` WHERE `+"`phone`"+` = ?;",`+
+		`"args":["***"],"slow":true,"caller":"user.go:10","duration":"1s","error":"mock error"}`+"\n", buf.String())
+}
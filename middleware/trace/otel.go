@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// OTelTracer 基于OpenTelemetry的Tracer
+type OTelTracer struct {
+	tracer oteltrace.Tracer
+}
+
+// NewOTelTracer tracer通常由otel.Tracer("simple_orm")获得
+func NewOTelTracer(tracer oteltrace.Tracer) *OTelTracer {
+	return &OTelTracer{
+		tracer: tracer,
+	}
+}
+
+func (o *OTelTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	ctx, span := o.tracer.Start(ctx, name, oteltrace.WithSpanKind(oteltrace.SpanKindClient))
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span oteltrace.Span
+}
+
+func (o *otelSpan) SetAttribute(key string, val string) {
+	o.span.SetAttributes(attribute.String(key, val))
+}
+
+func (o *otelSpan) RecordError(err error) {
+	o.span.RecordError(err)
+	o.span.SetStatus(codes.Error, err.Error())
+}
+
+func (o *otelSpan) TraceParent() string {
+	sc := o.span.SpanContext()
+	if !sc.IsValid() {
+		return ""
+	}
+	return "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-" + sc.TraceFlags().String()
+}
+
+func (o *otelSpan) End() {
+	o.span.End()
+}
@@ -0,0 +1,75 @@
+package trace
+
+import (
+	"context"
+	"github.com/simple_orm"
+	"net/url"
+	"strings"
+)
+
+type TraceMiddleWare struct {
+	tracer       Tracer
+	sqlCommenter bool
+}
+
+type TraceOption func(t *TraceMiddleWare)
+
+// WithSQLCommenter 在SQL末尾追加sqlcommenter格式的注释 /*traceparent='...'*/，
+// 使数据库侧的慢日志可以关联到请求链路。注释每次请求都不同，以SQL为键的中间件（如缓存）
+// 应放在trace之外，cache中间件生成键时会忽略结尾的注释
+func WithSQLCommenter() TraceOption {
+	return func(t *TraceMiddleWare) {
+		t.sqlCommenter = true
+	}
+}
+
+func NewTraceMiddleWare(tracer Tracer, opts ...TraceOption) *TraceMiddleWare {
+	t := &TraceMiddleWare{
+		tracer: tracer,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *TraceMiddleWare) Build() simple_orm.MiddleWare {
+	return func(next simple_orm.HandleFunc) simple_orm.HandleFunc {
+		return func(ctx context.Context, qc *simple_orm.QueryContext) *simple_orm.QueryResult {
+			table := ""
+			if qc.Model != nil {
+				table = qc.Model.TableName
+			}
+			name := qc.Type
+			if table != "" {
+				name = qc.Type + " " + table
+			}
+			ctx, span := t.tracer.Start(ctx, name)
+			defer span.End()
+			span.SetAttribute("db.system", qc.Dialect)
+			span.SetAttribute("db.operation", qc.Type)
+			span.SetAttribute("db.sql.table", table)
+			span.SetAttribute("db.statement", qc.Query.SQL)
+			if t.sqlCommenter {
+				if traceParent := span.TraceParent(); traceParent != "" {
+					qc.Query.SQL = appendComment(qc.Query.SQL, "traceparent", traceParent)
+				}
+			}
+			res := next(ctx, qc)
+			if res.Err != nil {
+				span.RecordError(res.Err)
+			}
+			return res
+		}
+	}
+}
+
+// appendComment 注释需要位于结尾的分号之前，值按照sqlcommenter规范进行URL编码
+func appendComment(sql string, key string, val string) string {
+	comment := "/*" + url.QueryEscape(key) + "='" + url.QueryEscape(val) + "'*/"
+	trimmed := strings.TrimRight(sql, " ")
+	if strings.HasSuffix(trimmed, ";") {
+		return strings.TrimSuffix(trimmed, ";") + " " + comment + ";"
+	}
+	return trimmed + " " + comment
+}
@@ -0,0 +1,92 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	tracer := NewMemoryTracer()
+	db, err := simple_orm.OpenDB(mockDB,
+		simple_orm.DBWithMiddleWare(NewTraceMiddleWare(tracer, WithSQLCommenter()).Build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery("SELECT \\* FROM `test_model` WHERE `id` = \\? " +
+		"/\\*traceparent='00-00000000000000000000000000000001-0000000000000001-01'\\*/;").
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	mock.ExpectExec("DELETE .*").WillReturnError(errors.New("mock error"))
+
+	_, err = simple_orm.NewSelector[model.TestModel](db).Where(simple_orm.NewColumn("Id").EQ(1)).
+		Get(context.Background())
+	assert.Nil(t, err)
+	_, err = simple_orm.NewDeleter[model.TestModel](db).Exec(context.Background())
+	assert.Equal(t, errors.New("mock error"), errors.Unwrap(err))
+
+	spans := tracer.Spans()
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "SELECT test_model", spans[0].Name)
+	assert.Equal(t, map[string]string{
+		"db.system":    "mysql",
+		"db.operation": "SELECT",
+		"db.sql.table": "test_model",
+		"db.statement": "SELECT * FROM `test_model` WHERE `id` = ?;",
+	}, spans[0].Attributes)
+	assert.Nil(t, spans[0].Err)
+	assert.True(t, spans[0].Ended)
+	assert.Equal(t, "DELETE test_model", spans[1].Name)
+	assert.Equal(t, errors.New("mock error"), errors.Unwrap(spans[1].Err))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestOTelTracer(t *testing.T) {
+	traceID, _ := oteltrace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := oteltrace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := oteltrace.ContextWithSpanContext(context.Background(), oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: oteltrace.FlagsSampled,
+	}))
+	tracer := NewOTelTracer(oteltrace.NewNoopTracerProvider().Tracer("simple_orm"))
+	_, span := tracer.Start(ctx, "SELECT test_model")
+	defer span.End()
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", span.TraceParent())
+
+	_, span = tracer.Start(context.Background(), "SELECT test_model")
+	assert.Equal(t, "", span.TraceParent())
+}
+
+func TestAppendComment(t *testing.T) {
+	testCases := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "with semicolon",
+			sql:  "SELECT * FROM `test_model`;",
+			want: "SELECT * FROM `test_model` /*traceparent='00-01-02-01'*/;",
+		},
+		{
+			name: "without semicolon",
+			sql:  "SELECT * FROM `test_model`",
+			want: "SELECT * FROM `test_model` /*traceparent='00-01-02-01'*/",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, appendComment(tc.sql, "traceparent", "00-01-02-01"))
+		})
+	}
+}
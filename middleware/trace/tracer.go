@@ -0,0 +1,79 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Tracer 链路追踪的最小抽象，可以对接OpenTelemetry等实现
+type Tracer interface {
+	// Start 开启一个span，返回的ctx中携带该span
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttribute(key string, val string)
+	RecordError(err error)
+	// TraceParent 返回W3C traceparent，span无效时返回空字符串
+	TraceParent() string
+	End()
+}
+
+// MemoryTracer 基于内存的Tracer，用于单测
+type MemoryTracer struct {
+	lock  sync.Mutex
+	spans []*MemorySpan
+	id    uint64
+}
+
+func NewMemoryTracer() *MemoryTracer {
+	return &MemoryTracer{}
+}
+
+func (m *MemoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	id := atomic.AddUint64(&m.id, 1)
+	span := &MemorySpan{
+		Name:       name,
+		Attributes: map[string]string{},
+		traceID:    id,
+		spanID:     id,
+	}
+	m.lock.Lock()
+	m.spans = append(m.spans, span)
+	m.lock.Unlock()
+	return ctx, span
+}
+
+// Spans 返回所有开启过的span
+func (m *MemoryTracer) Spans() []*MemorySpan {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]*MemorySpan(nil), m.spans...)
+}
+
+type MemorySpan struct {
+	Name       string
+	Attributes map[string]string
+	Err        error
+	Ended      bool
+	traceID    uint64
+	spanID     uint64
+}
+
+func (m *MemorySpan) SetAttribute(key string, val string) {
+	m.Attributes[key] = val
+}
+
+func (m *MemorySpan) RecordError(err error) {
+	m.Err = err
+}
+
+func (m *MemorySpan) TraceParent() string {
+	return fmt.Sprintf("00-%032x-%016x-01", m.traceID, m.spanID)
+}
+
+func (m *MemorySpan) End() {
+	m.Ended = true
+}
@@ -0,0 +1,212 @@
+package simple_orm
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestQueryContext(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	var (
+		gotQC  *QueryContext
+		gotRes *QueryResult
+	)
+	db, err := OpenDB(mockDB, DBWithMiddleWare(func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			gotQC = qc
+			gotRes = next(ctx, qc)
+			return gotRes
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// select
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	_, err = NewSelector[model.TestModel](db).Where(NewColumn("Id").EQ(1)).Get(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, QueryTypeSelect, gotQC.Type)
+	assert.Equal(t, "test_model", gotQC.Model.TableName)
+	assert.Equal(t, &Query{SQL: "SELECT * FROM `test_model` WHERE `id` = ?;", Args: []any{1}}, gotQC.Query)
+	assert.Equal(t, []string{"Id"}, gotQC.ArgColumns)
+
+	// insert
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(12, 1))
+	_, err = NewInserter[model.TestModel](db).Values(&model.TestModel{Id: 12}).Exec(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, QueryTypeInsert, gotQC.Type)
+	assert.Equal(t, "test_model", gotQC.Model.TableName)
+	assert.Equal(t, "INSERT INTO `test_model`(`id`,`first_name`,`age`) VALUES(?,?,?);", gotQC.Query.SQL)
+	assert.Equal(t, []string{"Id", "FirstName", "Age"}, gotQC.ArgColumns)
+	assert.Equal(t, int64(1), gotRes.RowsAffected)
+	assert.Equal(t, int64(12), gotRes.LastInsertId)
+
+	// delete
+	mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 3))
+	_, err = NewDeleter[model.TestModel](db).Where(NewColumn("Age").GT(18)).Exec(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, QueryTypeDelete, gotQC.Type)
+	assert.Equal(t, &Query{SQL: "DELETE FROM `test_model` WHERE `age` > ?;", Args: []any{18}}, gotQC.Query)
+	assert.Equal(t, int64(3), gotRes.RowsAffected)
+
+	// raw
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	_, err = NewRawQuery[model.TestModel](db, "SELECT * FROM `test_model` WHERE `id` = ?", 1).Get(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, QueryTypeRaw, gotQC.Type)
+	assert.Equal(t, &Query{SQL: "SELECT * FROM `test_model` WHERE `id` = ?", Args: []any{1}}, gotQC.Query)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddleWare_Order(t *testing.T) {
+	record := func(trace *[]string, name string) MiddleWare {
+		return func(next HandleFunc) HandleFunc {
+			return func(ctx context.Context, qc *QueryContext) *QueryResult {
+				*trace = append(*trace, name)
+				return next(ctx, qc)
+			}
+		}
+	}
+	testCases := []struct {
+		name      string
+		opts      func(trace *[]string) []DBOption
+		use       func(trace *[]string) []MiddleWare
+		wantTrace []string
+		wantErr   error
+	}{
+		{
+			name: "register order",
+			opts: func(trace *[]string) []DBOption {
+				return []DBOption{
+					DBWithMiddleWare(record(trace, "a"), record(trace, "b")),
+					DBWithNamedMiddleWare("log", record(trace, "log")),
+				}
+			},
+			wantTrace: []string{"a", "b", "log"},
+		},
+		{
+			name: "before and after",
+			opts: func(trace *[]string) []DBOption {
+				return []DBOption{
+					DBWithNamedMiddleWare("metric", record(trace, "metric"), MiddleWareAfter("log")),
+					DBWithNamedMiddleWare("log", record(trace, "log")),
+					DBWithNamedMiddleWare("trace", record(trace, "trace"), MiddleWareBefore("log")),
+				}
+			},
+			wantTrace: []string{"trace", "log", "metric"},
+		},
+		{
+			name: "per query",
+			opts: func(trace *[]string) []DBOption {
+				return []DBOption{DBWithNamedMiddleWare("log", record(trace, "log"))}
+			},
+			use: func(trace *[]string) []MiddleWare {
+				return []MiddleWare{record(trace, "q1"), record(trace, "q2")}
+			},
+			wantTrace: []string{"log", "q1", "q2"},
+		},
+		{
+			name: "unknown name",
+			opts: func(trace *[]string) []DBOption {
+				return []DBOption{DBWithNamedMiddleWare("log", record(trace, "log"), MiddleWareAfter("metric"))}
+			},
+			wantErr: errors.New("[middleware] unknown name metric"),
+		},
+		{
+			name: "duplicate name",
+			opts: func(trace *[]string) []DBOption {
+				return []DBOption{
+					DBWithNamedMiddleWare("log", record(trace, "log")),
+					DBWithNamedMiddleWare("log", record(trace, "log")),
+				}
+			},
+			wantErr: errors.New("[middleware] duplicate name log"),
+		},
+		{
+			name: "circular",
+			opts: func(trace *[]string) []DBOption {
+				return []DBOption{
+					DBWithNamedMiddleWare("a", record(trace, "a"), MiddleWareAfter("b")),
+					DBWithNamedMiddleWare("b", record(trace, "b"), MiddleWareAfter("a")),
+				}
+			},
+			wantErr: errors.New("[middleware] circular order"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = mockDB.Close() }()
+			trace := make([]string, 0)
+			db, err := OpenDB(mockDB, tc.opts(&trace)...)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+			s := NewSelector[model.TestModel](db)
+			useCnt := 0
+			if tc.use != nil {
+				use := tc.use(&trace)
+				useCnt = len(use)
+				s.Use(use...)
+			}
+			_, err = s.Get(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantTrace, trace)
+			// 单次查询的中间件不影响DB
+			assert.Equal(t, len(tc.wantTrace)-useCnt, len(db.middleWares))
+		})
+	}
+}
+
+func TestBuilder_ArgColumns(t *testing.T) {
+	db := memoryDB4UnitTest(t)
+	testCases := []struct {
+		name  string
+		where *Predicate
+		want  []string
+	}{
+		{
+			name:  "value on the left",
+			where: &Predicate{left: NewValue(1), op: model.OpEQ, right: NewColumn("Id")},
+			want:  []string{"Id"},
+		},
+		{
+			name:  "value list",
+			where: &Predicate{left: NewColumn("Id"), op: "IN", right: &rowValue{exprs: []Expression{NewValue(1), NewValue(2)}}},
+			want:  []string{"Id", "Id"},
+		},
+		{
+			name:  "row value",
+			where: seekPredicate([]*OrderBy{Asc("FirstName"), Asc("Id")}, []any{"Deng", int64(1)}, false),
+			want:  []string{"FirstName", "Id"},
+		},
+		{
+			name:  "expanded keyset",
+			where: seekPredicate([]*OrderBy{Asc("FirstName"), Desc("Id")}, []any{"Deng", int64(1)}, false),
+			want:  []string{"FirstName", "FirstName", "Id"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSelector[model.TestModel](db).Where(tc.where)
+			_, err := s.Build()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.want, s.argColumns())
+		})
+	}
+}
@@ -21,6 +21,27 @@ const (
 	OpLT  = "<"
 	OpGT  = ">"
 	OpEQ  = "="
+
+	OpIsNull    = "IS NULL"
+	OpIsNotNull = "IS NOT NULL"
+)
+
+// 标签中的选项，多个选项用逗号分隔，非选项部分作为标签名，eg：orm:"phone,sensitive"
+const (
+	TagSensitive  = "sensitive"
+	TagIndex      = "index"
+	TagSoftDelete = "soft_delete"
+
+	TagAutoCreateTime = "autoCreateTime"
+	TagAutoUpdateTime = "autoUpdateTime"
+	TagVersion        = "version"
+)
+
+// 未配置标签时，按字段名识别软删除与自动时间字段
+const (
+	SoftDeleteFieldName     = "DeletedAt"
+	AutoCreateTimeFieldName = "CreatedAt"
+	AutoUpdateTimeFieldName = "UpdatedAt"
 )
 
 type Field struct {
@@ -28,13 +49,34 @@ type Field struct {
 	Typ        reflect.Type
 	TypName    string
 	Offset     uintptr
+	Sensitive  bool // 敏感字段，打印日志时脱敏，标签 orm:"sensitive"
+	Index      bool // 索引列（包括主键），标签 orm:"index"
+	SoftDelete bool // 软删除字段，记录删除时间，标签 orm:"soft_delete"
+
+	AutoCreateTime bool // 插入时写入创建时间，标签 orm:"autoCreateTime"
+	AutoUpdateTime bool // 插入与更新时写入更新时间，标签 orm:"autoUpdateTime"
+	Version        bool // 乐观锁版本号，更新时自增并作为条件，标签 orm:"version"
 }
 
 type TableModel struct {
 	TableName   string            // 表名
 	Tag2Field   map[string]*Field // 标签名到字段的映射
 	Col2Field   map[string]*Field // 列名到字段的映射
+	DBCol2Field map[string]*Field // 数据库列名到字段的映射，eg：first_name
 	ColumnNames []string          // 列名数组，由于map的遍历是乱序，因此用数组保证顺序
+	SoftDelete  *Field            // 软删除字段，没有时为nil
+	CreateTime  *Field            // 自动写入的创建时间字段，没有时为nil
+	UpdateTime  *Field            // 自动写入的更新时间字段，没有时为nil
+	Version     *Field            // 乐观锁版本号字段，没有时为nil
+}
+
+// FieldByColumn 查找结果集中的列对应的字段，支持字段名与数据库列名
+func (t *TableModel) FieldByColumn(column string) (*Field, bool) {
+	if field, ok := t.Col2Field[column]; ok {
+		return field, true
+	}
+	field, ok := t.DBCol2Field[column]
+	return field, ok
 }
 
 // Registry 注册中心，存储表信息
@@ -1,8 +1,11 @@
 package model
 
 import (
+	"database/sql"
 	"errors"
 	"reflect"
+	"strings"
+	"time"
 	"unicode"
 )
 
@@ -39,7 +42,9 @@ func (r *Registry) parseModel(typ reflect.Type) (*TableModel, error) {
 	}
 	tag2Field := map[string]*Field{}
 	col2Field := map[string]*Field{}
+	dbCol2Field := map[string]*Field{}
 	columnNames := make([]string, 0)
+	var softDelete, createTime, updateTime, version *Field
 	for i := 0; i < typ.NumField(); i++ {
 		fd := typ.Field(i)
 		fdName := fd.Name
@@ -50,7 +55,7 @@ func (r *Registry) parseModel(typ reflect.Type) (*TableModel, error) {
 			}
 			fieldMap中的key是title，若没配置orm则key是name
 		*/
-		tag := fd.Tag.Get("orm")
+		tag, options := parseTag(fd.Tag.Get("orm"))
 		// 若不配置标签默认取typeName
 		if tag == "" {
 			tag = fdName
@@ -62,17 +67,125 @@ func (r *Registry) parseModel(typ reflect.Type) (*TableModel, error) {
 			TypName:    fd.Name,
 			Offset:     fd.Offset,
 		}
+		_, field.Sensitive = options[TagSensitive]
+		_, field.Index = options[TagIndex]
+		// 标签优先于字段名。未删除的行该列必须为NULL，因此只接受可以为NULL的类型，
+		// 按字段名识别时类型不符则视为普通字段
+		if _, ok := options[TagSoftDelete]; ok {
+			if softDelete != nil && softDelete.SoftDelete {
+				return nil, errors.New("multiple soft delete fields")
+			}
+			if !nullableTime(fd.Type) {
+				return nil, errors.New("soft delete field must be *time.Time, sql.NullTime or pointer to integer")
+			}
+			field.SoftDelete = true
+			softDelete = field
+		} else if fdName == SoftDeleteFieldName && softDelete == nil && nullableTime(fd.Type) {
+			softDelete = field
+		}
+		// 按字段名识别时，类型不是时间或整型的字段视为普通字段
+		_, autoCreate := options[TagAutoCreateTime]
+		_, autoUpdate := options[TagAutoUpdateTime]
+		if (autoCreate || autoUpdate) && !autoTime(fd.Type) {
+			return nil, errors.New("auto time field must be time.Time, *time.Time, sql.NullTime or integer")
+		}
+		if autoCreate {
+			field.AutoCreateTime = true
+			createTime = field
+		} else if fdName == AutoCreateTimeFieldName && createTime == nil && autoTime(fd.Type) {
+			createTime = field
+		}
+		if autoUpdate {
+			field.AutoUpdateTime = true
+			updateTime = field
+		} else if fdName == AutoUpdateTimeFieldName && updateTime == nil && autoTime(fd.Type) {
+			updateTime = field
+		}
+		if _, ok := options[TagVersion]; ok {
+			if version != nil {
+				return nil, errors.New("multiple version fields")
+			}
+			switch fd.Type.Kind() {
+			case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+			default:
+				return nil, errors.New("version field must be integer")
+			}
+			field.Version = true
+			version = field
+		}
 		tag2Field[tag] = field
 		col2Field[fdName] = field
+		dbCol2Field[field.ColumnName] = field
+	}
+	if softDelete != nil {
+		softDelete.SoftDelete = true
+	}
+	if createTime != nil {
+		createTime.AutoCreateTime = true
+	}
+	if updateTime != nil {
+		updateTime.AutoUpdateTime = true
 	}
 	return &TableModel{
 		TableName:   underscoreName(typ.Name()),
 		Tag2Field:   tag2Field,
 		Col2Field:   col2Field,
+		DBCol2Field: dbCol2Field,
 		ColumnNames: columnNames,
+		SoftDelete:  softDelete,
+		CreateTime:  createTime,
+		UpdateTime:  updateTime,
+		Version:     version,
 	}, nil
 }
 
+// nullableTime 可以为NULL的时间类型：*time.Time、sql.NullTime或整型指针（unix秒）
+func nullableTime(typ reflect.Type) bool {
+	if typ == reflect.TypeOf(sql.NullTime{}) {
+		return true
+	}
+	if typ.Kind() != reflect.Ptr {
+		return false
+	}
+	switch typ.Elem().Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return typ.Elem() == reflect.TypeOf(time.Time{})
+}
+
+// autoTime 可以自动写入时间的类型：time.Time、*time.Time、sql.NullTime或整型（unix秒）
+func autoTime(typ reflect.Type) bool {
+	switch typ {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(&time.Time{}), reflect.TypeOf(sql.NullTime{}):
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// parseTag 将orm标签拆分成标签名与选项，只有第一部分可以是标签名，无法识别的选项忽略
+func parseTag(tag string) (string, map[string]struct{}) {
+	name := ""
+	options := map[string]struct{}{}
+	for i, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "":
+		case TagSensitive, TagIndex, TagSoftDelete, TagAutoCreateTime, TagAutoUpdateTime, TagVersion:
+			options[part] = struct{}{}
+		default:
+			if i == 0 {
+				name = part
+			}
+		}
+	}
+	return name, options
+}
+
 // underscoreName 驼峰转字符串命名
 func underscoreName(tableName string) string {
 	var buf []byte
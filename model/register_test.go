@@ -1,10 +1,12 @@
 package model
 
 import (
+	"database/sql"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestRegistry_Get(t *testing.T) {
@@ -163,3 +165,205 @@ func TestRegistry_Get(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTag(t *testing.T) {
+	testCases := []struct {
+		name        string
+		tag         string
+		wantName    string
+		wantOptions map[string]struct{}
+	}{
+		{
+			name:        "empty",
+			tag:         "",
+			wantOptions: map[string]struct{}{},
+		},
+		{
+			name:        "name only",
+			tag:         "identity",
+			wantName:    "identity",
+			wantOptions: map[string]struct{}{},
+		},
+		{
+			name:        "option only",
+			tag:         "sensitive",
+			wantOptions: map[string]struct{}{TagSensitive: {}},
+		},
+		{
+			name:        "name and option",
+			tag:         "phone, sensitive",
+			wantName:    "phone",
+			wantOptions: map[string]struct{}{TagSensitive: {}},
+		},
+		{
+			// 无法识别的选项不会覆盖标签名
+			name:        "unknown option",
+			tag:         "phone,sensitive,omitempty",
+			wantName:    "phone",
+			wantOptions: map[string]struct{}{TagSensitive: {}},
+		},
+		{
+			name:        "unknown option without name",
+			tag:         "sensitive,omitempty",
+			wantOptions: map[string]struct{}{TagSensitive: {}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, options := parseTag(tc.tag)
+			assert.Equal(t, tc.wantName, name)
+			assert.Equal(t, tc.wantOptions, options)
+		})
+	}
+}
+
+func TestRegistry_SoftDelete(t *testing.T) {
+	type ByName struct {
+		Id        int64
+		DeletedAt *time.Time
+	}
+	type ByTag struct {
+		DeletedAt *time.Time
+		RemovedAt sql.NullTime `orm:"soft_delete"`
+	}
+	type Multiple struct {
+		DeletedAt *int64 `orm:"soft_delete"`
+		RemovedAt *int64 `orm:"soft_delete"`
+	}
+	type NotNullByTag struct {
+		RemovedAt int64 `orm:"soft_delete"`
+	}
+	type NotNullByName struct {
+		DeletedAt time.Time
+	}
+	testCases := []struct {
+		name     string
+		val      any
+		wantName string
+		wantErr  error
+	}{
+		{
+			name:     "by name",
+			val:      &ByName{},
+			wantName: "DeletedAt",
+		},
+		{
+			// 标签优先于字段名
+			name:     "by tag",
+			val:      &ByTag{},
+			wantName: "RemovedAt",
+		},
+		{
+			name:    "multiple",
+			val:     &Multiple{},
+			wantErr: errors.New("multiple soft delete fields"),
+		},
+		{
+			// 非NULL类型写入零值后，新插入的行会被当作已删除
+			name:    "not nullable by tag",
+			val:     &NotNullByTag{},
+			wantErr: errors.New("soft delete field must be *time.Time, sql.NullTime or pointer to integer"),
+		},
+		{
+			name: "not nullable by name",
+			val:  &NotNullByName{},
+		},
+		{
+			name: "none",
+			val:  &TestModel{},
+		},
+	}
+	r := NewRegistry()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := r.Get(tc.val)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			if tc.wantName == "" {
+				assert.Nil(t, m.SoftDelete)
+				return
+			}
+			assert.Equal(t, tc.wantName, m.SoftDelete.TypName)
+			assert.True(t, m.SoftDelete.SoftDelete)
+		})
+	}
+}
+
+func TestRegistry_AutoTime(t *testing.T) {
+	type ByName struct {
+		CreatedAt time.Time
+		UpdatedAt int64
+	}
+	type NotTimeByName struct {
+		CreatedAt string
+		UpdatedAt []byte
+	}
+	type NotTimeByTag struct {
+		ModifyAt string `orm:"autoUpdateTime"`
+	}
+	r := NewRegistry()
+	m, err := r.Get(&ByName{})
+	assert.Nil(t, err)
+	assert.Equal(t, "CreatedAt", m.CreateTime.TypName)
+	assert.Equal(t, "UpdatedAt", m.UpdateTime.TypName)
+
+	// 仅字段名相同不视为自动时间字段
+	m, err = r.Get(&NotTimeByName{})
+	assert.Nil(t, err)
+	assert.Nil(t, m.CreateTime)
+	assert.Nil(t, m.UpdateTime)
+
+	_, err = r.Get(&NotTimeByTag{})
+	assert.Equal(t, errors.New("auto time field must be time.Time, *time.Time, sql.NullTime or integer"), err)
+}
+
+func TestRegistry_Version(t *testing.T) {
+	type Valid struct {
+		Version uint32 `orm:"version"`
+	}
+	type NotInteger struct {
+		Version string `orm:"version"`
+	}
+	type Multiple struct {
+		Version  int64 `orm:"version"`
+		Revision int64 `orm:"version"`
+	}
+	r := NewRegistry()
+	m, err := r.Get(&Valid{})
+	assert.Nil(t, err)
+	assert.True(t, m.Version.Version)
+
+	_, err = r.Get(&NotInteger{})
+	assert.Equal(t, errors.New("version field must be integer"), err)
+
+	_, err = r.Get(&Multiple{})
+	assert.Equal(t, errors.New("multiple version fields"), err)
+}
+
+func TestTableModel_FieldByColumn(t *testing.T) {
+	m, err := NewRegistry().Get(&TestModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		column    string
+		wantField string
+		wantOk    bool
+	}{
+		{column: "FirstName", wantField: "FirstName", wantOk: true},
+		{column: "first_name", wantField: "FirstName", wantOk: true},
+		{column: "firstName"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.column, func(t *testing.T) {
+			field, ok := m.FieldByColumn(tc.column)
+			assert.Equal(t, tc.wantOk, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tc.wantField, field.TypName)
+		})
+	}
+}
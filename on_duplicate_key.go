@@ -6,4 +6,5 @@ type UpsertBuilder[T any] struct {
 
 type UpsertKey struct {
 	assigns []Assignable
+	where   []*Predicate // 冲突时只更新满足条件的行，如租户
 }
@@ -0,0 +1,100 @@
+package simple_orm
+
+import (
+	"context"
+	"errors"
+	"github.com/hashicorp/go-multierror"
+	"sync"
+)
+
+// Page 分页查询的结果，Page从1开始
+type Page[T any] struct {
+	Items []*T
+	Total int64
+	Page  int
+	Size  int
+}
+
+type pageOptions struct {
+	concurrent bool
+}
+
+type PageOption func(opts *pageOptions)
+
+// PageWithConcurrent 并发执行数据查询与COUNT查询，事务中的连接不能并发使用，此时仍然顺序执行
+func PageWithConcurrent() PageOption {
+	return func(opts *pageOptions) {
+		opts.concurrent = true
+	}
+}
+
+// Page 查询第page页的数据与总数，总数由去掉ORDER BY/LIMIT/OFFSET的COUNT(*)查询得到
+func (s *Selector[T]) Page(ctx context.Context, page int, size int, opts ...PageOption) (*Page[T], error) {
+	if page < 1 || size < 1 {
+		return nil, errors.New("[page] page and size must be positive")
+	}
+	options := &pageOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	counter := s.countSelector()
+	s.limit = size
+	s.offset = (page - 1) * size
+	res := &Page[T]{
+		Page: page,
+		Size: size,
+	}
+	_, inTx := s.session.(*TX)
+	if !options.concurrent || inTx {
+		total, err := count[T](ctx, s.core, s.session, counter)
+		if err != nil {
+			return nil, err
+		}
+		res.Total = total
+		// 超出总数时不需要再查询数据
+		if total <= int64(s.offset) {
+			res.Items = make([]*T, 0)
+			return res, nil
+		}
+		res.Items, err = s.GetMul(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	var (
+		wg                 sync.WaitGroup
+		countErr, itemsErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		res.Total, countErr = count[T](ctx, s.core, s.session, counter)
+	}()
+	res.Items, itemsErr = s.GetMul(ctx)
+	wg.Wait()
+	switch {
+	case countErr != nil && itemsErr != nil:
+		return nil, multierror.Append(countErr, itemsErr)
+	case countErr != nil:
+		return nil, countErr
+	case itemsErr != nil:
+		return nil, itemsErr
+	}
+	return res, nil
+}
+
+// countSelector 复制查询条件构造COUNT查询，不包括排序、分页与行锁
+func (s *Selector[T]) countSelector() *Selector[T] {
+	return &Selector[T]{
+		core:     s.core,
+		session:  s.session,
+		table:    s.table,
+		where:    s.where,
+		groupBy:  s.groupBy,
+		having:   s.having,
+		unscoped: s.unscoped,
+		count:    true,
+	}
+}
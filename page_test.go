@@ -0,0 +1,124 @@
+package simple_orm
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSelector_CountBuild(t *testing.T) {
+	db := memoryDB4UnitTest(t)
+	testCases := []struct {
+		name      string
+		s         *Selector[model.TestModel]
+		wantQuery *Query
+	}{
+		{
+			// 去掉排序与分页
+			name: "count",
+			s: NewSelector[model.TestModel](db).Where(NewColumn("Age").GT(18)).
+				OrderBy(Asc("Id")).Limit(10).Offset(20),
+			wantQuery: &Query{
+				SQL:  "SELECT COUNT(*) FROM `test_model` WHERE `age` > ?;",
+				Args: []any{18},
+			},
+		},
+		{
+			// 分组时统计组数
+			name: "group by",
+			s:    NewSelector[model.TestModel](db).GroupBy(NewColumn("Age")).Having(Sum("Id").GT(10)),
+			wantQuery: &Query{
+				SQL:  "SELECT COUNT(*) FROM (SELECT `age` FROM `test_model` GROUP BY `age` HAVING SUM(`id`) > ?) AS `t`;",
+				Args: []any{10},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.s.countSelector().Build()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_Page(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT COUNT(*) FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+	mock.ExpectQuery("SELECT * FROM `test_model` ORDER BY `id` ASC LIMIT ? OFFSET ?;").
+		WithArgs(2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(3))
+	page, err := NewSelector[model.TestModel](db).OrderBy(Asc("Id")).Page(ctx, 2, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, &Page[model.TestModel]{
+		Items: []*model.TestModel{{Id: 3}},
+		Total: 3,
+		Page:  2,
+		Size:  2,
+	}, page)
+
+	// 超出总数时不查询数据
+	mock.ExpectQuery("SELECT COUNT(*) FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+	page, err = NewSelector[model.TestModel](db).Page(ctx, 3, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Empty(t, page.Items)
+
+	// 事务中即使指定并发也顺序执行
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT(*) FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+	mock.ExpectQuery("SELECT * FROM `test_model` LIMIT ?;").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	mock.ExpectCommit()
+	err = db.doTx(ctx, func(ctx context.Context, tx *TX) error {
+		page, err = NewSelector[model.TestModel](tx).Page(ctx, 1, 2, PageWithConcurrent())
+		return err
+	}, &sql.TxOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), page.Total)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	_, err = NewSelector[model.TestModel](db).Page(ctx, 0, 2)
+	assert.NotNil(t, err)
+}
+
+func TestSelector_PageConcurrent(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 两个查询的执行顺序不确定
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery("SELECT COUNT(*) FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(5))
+	mock.ExpectQuery("SELECT * FROM `test_model` LIMIT ?;").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1).AddRow(2))
+	page, err := NewSelector[model.TestModel](db).Page(context.Background(), 1, 2, PageWithConcurrent())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), page.Total)
+	assert.Len(t, page.Items, 2)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
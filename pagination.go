@@ -0,0 +1,265 @@
+package simple_orm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/simple_orm/model"
+	"reflect"
+)
+
+// ErrInvalidCursor 游标无法解析或与排序列不匹配
+var ErrInvalidCursor = errors.New("[paginate] invalid cursor")
+
+// CursorPage 游标分页的一页数据，游标为空字符串表示没有对应的页
+type CursorPage[T any] struct {
+	Items []*T
+	Next  string
+	Prev  string
+}
+
+// cursor 游标中保存排序列的值，base64编码后对调用方不透明
+type cursor struct {
+	Values   []json.RawMessage `json:"v"`
+	Backward bool              `json:"b,omitempty"` // 向前翻页
+}
+
+// rowValue 行值，eg：(`a`,`b`)、(?,?)
+type rowValue struct {
+	exprs []Expression
+}
+
+func (r *rowValue) expr() {
+
+}
+
+// Paginate 基于OrderBy的列做游标分页，cursor为空时返回第一页。
+// 排序列需要能唯一确定一行（如最后加上主键），否则翻页时可能遗漏数据
+func (s *Selector[T]) Paginate(ctx context.Context, cursor string, pageSize int) (*CursorPage[T], error) {
+	if pageSize <= 0 {
+		return nil, errors.New("[paginate] page size must be positive")
+	}
+	if len(s.orderBy) == 0 {
+		return nil, errors.New("[paginate] order by is required")
+	}
+	var t T
+	tableModel, err := s.r.Get(t)
+	if err != nil {
+		return nil, err
+	}
+	orderBy := s.orderBy
+	backward, err := s.seek(tableModel, cursor, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.GetMul(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(items) > pageSize
+	if hasMore {
+		items = items[:pageSize]
+	}
+	// 向前翻页时按相反顺序查询，结果需要反转回来
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	page := &CursorPage[T]{Items: items}
+	if len(items) == 0 {
+		return page, nil
+	}
+	hasNext, hasPrev := hasMore, cursor != ""
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+	if hasNext {
+		page.Next, err = encodeCursor(s.core, tableModel, items[len(items)-1], orderBy, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		page.Prev, err = encodeCursor(s.core, tableModel, items[0], orderBy, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// seek 按游标追加定位条件与排序，多查询一行用于判断是否还有数据
+func (s *Selector[T]) seek(tableModel *model.TableModel, str string, pageSize int) (bool, error) {
+	values, backward, err := decodeCursor(tableModel, s.orderBy, str)
+	if err != nil {
+		return false, err
+	}
+	if values != nil {
+		s.where = append(s.where[:len(s.where):len(s.where)], seekPredicate(s.orderBy, values, backward))
+	}
+	if backward {
+		s.orderBy = reverseOrder(s.orderBy)
+	}
+	s.limit = pageSize + 1
+	return backward, nil
+}
+
+// Paginate 为每个分片构造游标分页的查询，每个分片最多返回pageSize+1行。
+// 调用方按OrderBy合并各分片结果后取前pageSize行，多出的一行表示还有数据，再使用NextCursor/PrevCursor生成游标；
+// 向前翻页（PrevCursor生成的游标）时查询按相反顺序排序，合并后需要反转
+func (s *ShardingSelector[T]) Paginate(cursor string, pageSize int) ([]*Query, error) {
+	if pageSize <= 0 {
+		return nil, errors.New("[paginate] page size must be positive")
+	}
+	if len(s.orderBy) == 0 {
+		return nil, errors.New("[paginate] order by is required")
+	}
+	var t T
+	tableModel, err := s.r.Get(t)
+	if err != nil {
+		return nil, err
+	}
+	values, backward, err := decodeCursor(tableModel, s.orderBy, cursor)
+	if err != nil {
+		return nil, err
+	}
+	if values != nil {
+		s.seekWhere = seekPredicate(s.orderBy, values, backward)
+	}
+	if backward {
+		s.orderBy = reverseOrder(s.orderBy)
+	}
+	s.limit = pageSize + 1
+	return s.Build()
+}
+
+// NextCursor 以item为当前页最后一行生成下一页的游标，orderBy需要与查询一致
+func NextCursor[T any](session Session, item *T, orderBy ...*OrderBy) (string, error) {
+	return cursorOf(session, item, orderBy, false)
+}
+
+// PrevCursor 以item为当前页第一行生成上一页的游标，orderBy需要与查询一致
+func PrevCursor[T any](session Session, item *T, orderBy ...*OrderBy) (string, error) {
+	return cursorOf(session, item, orderBy, true)
+}
+
+func cursorOf[T any](session Session, item *T, orderBy []*OrderBy, backward bool) (string, error) {
+	core := session.getCore()
+	var t T
+	tableModel, err := core.r.Get(t)
+	if err != nil {
+		return "", err
+	}
+	return encodeCursor(core, tableModel, item, orderBy, backward)
+}
+
+func encodeCursor(core core, tableModel *model.TableModel, item any, orderBy []*OrderBy, backward bool) (string, error) {
+	val := core.creator(item, tableModel)
+	c := cursor{
+		Values:   make([]json.RawMessage, 0, len(orderBy)),
+		Backward: backward,
+	}
+	for _, o := range orderBy {
+		colVal, err := val.GetValByColName(o.name)
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(colVal)
+		if err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, data)
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 解析游标，按排序列的类型还原值，空游标返回nil
+func decodeCursor(tableModel *model.TableModel, orderBy []*OrderBy, str string) ([]any, bool, error) {
+	if str == "" {
+		return nil, false, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	var c cursor
+	if err = json.Unmarshal(data, &c); err != nil || len(c.Values) != len(orderBy) {
+		return nil, false, ErrInvalidCursor
+	}
+	values := make([]any, 0, len(orderBy))
+	for i, o := range orderBy {
+		field, ok := tableModel.Col2Field[o.name]
+		if !ok {
+			return nil, false, errors.New("illegal field")
+		}
+		val := reflect.New(field.Typ)
+		if err = json.Unmarshal(c.Values[i], val.Interface()); err != nil {
+			return nil, false, ErrInvalidCursor
+		}
+		values = append(values, val.Elem().Interface())
+	}
+	return values, c.Backward, nil
+}
+
+// seekPredicate 定位到游标之后的条件。排序方向一致时使用行值比较 (`a`,`b`) > (?,?)，
+// 否则展开为 (`a` > ?) OR ((`a` = ?) AND (`b` < ?))
+func seekPredicate(orderBy []*OrderBy, values []any, backward bool) *Predicate {
+	op := func(o *OrderBy) model.Op {
+		// 升序向后翻页取更大的值，降序或向前翻页时相反
+		if (o.order == ASCOrder) != backward {
+			return model.OpGT
+		}
+		return model.OpLT
+	}
+	sameOrder := true
+	for _, o := range orderBy {
+		if o.order != orderBy[0].order {
+			sameOrder = false
+			break
+		}
+	}
+	if len(orderBy) == 1 {
+		return &Predicate{left: NewColumn(orderBy[0].name), op: op(orderBy[0]), right: NewValue(values[0])}
+	}
+	if sameOrder {
+		columns := &rowValue{}
+		vals := &rowValue{}
+		for i, o := range orderBy {
+			columns.exprs = append(columns.exprs, NewColumn(o.name))
+			vals.exprs = append(vals.exprs, NewValue(values[i]))
+		}
+		return &Predicate{left: columns, op: op(orderBy[0]), right: vals}
+	}
+	var res *Predicate
+	for i, o := range orderBy {
+		p := &Predicate{left: NewColumn(o.name), op: op(o), right: NewValue(values[i])}
+		for j := i - 1; j >= 0; j-- {
+			p = NewColumn(orderBy[j].name).EQ(values[j]).And(p)
+		}
+		if res == nil {
+			res = p
+		} else {
+			res = res.Or(p)
+		}
+	}
+	return res
+}
+
+// reverseOrder 反转排序方向
+func reverseOrder(orderBy []*OrderBy) []*OrderBy {
+	res := make([]*OrderBy, 0, len(orderBy))
+	for _, o := range orderBy {
+		if o.order == ASCOrder {
+			res = append(res, Desc(o.name))
+		} else {
+			res = append(res, Asc(o.name))
+		}
+	}
+	return res
+}
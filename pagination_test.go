@@ -0,0 +1,145 @@
+package simple_orm
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm/model"
+	"github.com/simple_orm/sharding"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSeekPredicate(t *testing.T) {
+	db := memoryDB4UnitTest(t)
+	testCases := []struct {
+		name      string
+		orderBy   []*OrderBy
+		values    []any
+		backward  bool
+		wantQuery *Query
+	}{
+		{
+			name:    "single column",
+			orderBy: []*OrderBy{Asc("Id")},
+			values:  []any{int64(10)},
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` > ?;",
+				Args: []any{int64(10)},
+			},
+		},
+		{
+			name:    "row value",
+			orderBy: []*OrderBy{Desc("Age"), Desc("Id")},
+			values:  []any{int8(18), int64(10)},
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`age`,`id`) < (?,?);",
+				Args: []any{int8(18), int64(10)},
+			},
+		},
+		{
+			name:     "row value backward",
+			orderBy:  []*OrderBy{Asc("Age"), Asc("Id")},
+			values:   []any{int8(18), int64(10)},
+			backward: true,
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`age`,`id`) < (?,?);",
+				Args: []any{int8(18), int64(10)},
+			},
+		},
+		{
+			// 排序方向不一致时展开
+			name:    "mixed order",
+			orderBy: []*OrderBy{Desc("Age"), Asc("Id")},
+			values:  []any{int8(18), int64(10)},
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`age` < ?) OR ((`age` = ?) AND (`id` > ?));",
+				Args: []any{int8(18), int8(18), int64(10)},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := NewSelector[model.TestModel](db).
+				Where(seekPredicate(tc.orderBy, tc.values, tc.backward)).Build()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_Paginate(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	orderBy := []*OrderBy{Desc("Age"), Desc("Id")}
+
+	// 第一页，多查询一行判断是否有下一页
+	mock.ExpectQuery("SELECT * FROM `test_model` ORDER BY `age` DESC,`id` DESC LIMIT ?;").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "Age"}).AddRow(5, 30).AddRow(4, 30).AddRow(3, 20))
+	page, err := NewSelector[model.TestModel](db).OrderBy(orderBy...).Paginate(ctx, "", 2)
+	assert.Nil(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "", page.Prev)
+	assert.NotEqual(t, "", page.Next)
+
+	// 下一页，最后一页没有下一页游标
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE (`age`,`id`) < (?,?) ORDER BY `age` DESC,`id` DESC LIMIT ?;").
+		WithArgs(int8(30), int64(4), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "Age"}).AddRow(3, 20))
+	page, err = NewSelector[model.TestModel](db).OrderBy(orderBy...).Paginate(ctx, page.Next, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []*model.TestModel{{Id: 3, Age: 20}}, page.Items)
+	assert.Equal(t, "", page.Next)
+	assert.NotEqual(t, "", page.Prev)
+
+	// 上一页，反向查询后结果恢复原顺序
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE (`age`,`id`) > (?,?) ORDER BY `age` ASC,`id` ASC LIMIT ?;").
+		WithArgs(int8(20), int64(3), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "Age"}).AddRow(4, 30).AddRow(5, 30))
+	page, err = NewSelector[model.TestModel](db).OrderBy(orderBy...).Paginate(ctx, page.Prev, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []*model.TestModel{{Id: 5, Age: 30}, {Id: 4, Age: 30}}, page.Items)
+	assert.Equal(t, "", page.Prev)
+	assert.NotEqual(t, "", page.Next)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	// 非法游标
+	_, err = NewSelector[model.TestModel](db).OrderBy(orderBy...).Paginate(ctx, "invalid", 2)
+	assert.Equal(t, ErrInvalidCursor, err)
+	_, err = NewSelector[model.TestModel](db).Paginate(ctx, "", 2)
+	assert.NotNil(t, err)
+}
+
+func TestShardingSelector_Paginate(t *testing.T) {
+	db, err := Open("sqlite3", "file:test.db?cache=shared&mode=memory",
+		DBWithShardingAlgorithm(sharding.NewHashAlgorithm("Id", map[string]struct{}{}, &sharding.Pattern{
+			Base: 2, DefaultName: "order_db", IsSharding: true,
+		}, &sharding.Pattern{
+			DefaultName: "order_tab",
+		})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := NextCursor[model.TestModel](db, &model.TestModel{Id: 10}, Asc("Id"))
+	assert.Nil(t, err)
+	queries, err := NewShardingSelector[model.TestModel](db).OrderBy(Asc("Id")).Paginate(next, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Query{
+		{
+			SQL:  "SELECT * FROM order_db0.order_tab WHERE `id` > ? ORDER BY `id` ASC LIMIT ?;",
+			Args: []any{int64(10), 21},
+		},
+		{
+			SQL:  "SELECT * FROM order_db1.order_tab WHERE `id` > ? ORDER BY `id` ASC LIMIT ?;",
+			Args: []any{int64(10), 21},
+		},
+	}, queries)
+}
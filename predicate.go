@@ -41,3 +41,24 @@ func Not(right *Predicate) *Predicate {
 		right: right,
 	}
 }
+
+// columnsOf 收集表达式中引用的列名
+func columnsOf(predicates ...*Predicate) []string {
+	res := make([]string, 0)
+	var walk func(e Expression)
+	walk = func(e Expression) {
+		switch expr := e.(type) {
+		case *Column:
+			res = append(res, expr.name)
+		case *Aggregate:
+			res = append(res, expr.name)
+		case *Predicate:
+			walk(expr.left)
+			walk(expr.right)
+		}
+	}
+	for _, p := range predicates {
+		walk(p)
+	}
+	return res
+}
@@ -7,12 +7,12 @@ import (
 type RawQuery[T any] struct {
 	Builder         // builder是Insert & Select公共部分
 	core            // core中是元数据信息
-	session session // session是db或tx
+	session Session // session是db或tx
 	sql     string  // 原生查询的SQL
 	args    []any   // 原生查询的参数
 }
 
-func NewRawQuery[T any](session session, sql string, args ...any) *RawQuery[T] {
+func NewRawQuery[T any](session Session, sql string, args ...any) *RawQuery[T] {
 	return &RawQuery[T]{
 		core:    session.getCore(),
 		session: session,
@@ -21,6 +21,13 @@ func NewRawQuery[T any](session session, sql string, args ...any) *RawQuery[T] {
 	}
 }
 
+// Use 添加仅对本次查询生效的中间件，在DB级别的中间件之后执行
+func (r *RawQuery[T]) Use(middleWares ...MiddleWare) *RawQuery[T] {
+	// 限制容量，避免append时修改DB共享的底层数组
+	r.middleWares = append(r.middleWares[:len(r.middleWares):len(r.middleWares)], middleWares...)
+	return r
+}
+
 func (r *RawQuery[T]) Build() (*Query, error) {
 	return &Query{
 		SQL:  r.sql,
@@ -29,13 +36,9 @@ func (r *RawQuery[T]) Build() (*Query, error) {
 }
 
 func (r *RawQuery[T]) Get(ctx context.Context) (*T, error) {
-	return get[T](ctx, r.core, r.session, &QueryContext{
-		Builder: r,
-	})
+	return get[T](ctx, r.core, r.session, QueryTypeRaw, r)
 }
 
 func (r *RawQuery[T]) GetMul(ctx context.Context) ([]*T, error) {
-	return getMul[T](ctx, r.core, r.session, &QueryContext{
-		Builder: r,
-	})
+	return getMul[T](ctx, r.core, r.session, QueryTypeRaw, r)
 }
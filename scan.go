@@ -0,0 +1,94 @@
+package simple_orm
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// rowScanner 将当前行写入新的T
+type rowScanner[T any] func(rows *sql.Rows) (*T, error)
+
+// newRowScanner 根据T选择映射方式：基本类型读取单列，map[string]any按列名读取所有列，
+// 结构体（不需要是注册过的模型）按列名匹配字段
+func newRowScanner[T any](core core) (rowScanner[T], error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() == reflect.Map {
+		if typ.Key().Kind() != reflect.String || typ.Elem().Kind() != reflect.Interface {
+			return nil, errors.New("[scan] map result must be map[string]any")
+		}
+		return scanMap[T], nil
+	}
+	if isScalar(typ) {
+		return scanScalar[T], nil
+	}
+	tableModel, err := core.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	return func(rows *sql.Rows) (*T, error) {
+		tp := new(T)
+		if err := core.creator(tp, tableModel).SetColumns(rows); err != nil {
+			return nil, err
+		}
+		return tp, nil
+	}, nil
+}
+
+// isScalar 非结构体，或time.Time、sql.NullString等实现了sql.Scanner的结构体
+func isScalar(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return true
+	}
+	return typ == timeType || reflect.PtrTo(typ).Implements(scannerType)
+}
+
+func scanScalar[T any](rows *sql.Rows) (*T, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) != 1 {
+		return nil, errors.New("[scan] scalar result requires exactly one column")
+	}
+	tp := new(T)
+	if err = rows.Scan(tp); err != nil {
+		return nil, err
+	}
+	return tp, nil
+}
+
+func scanMap[T any](rows *sql.Rows) (*T, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	if err = rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	tp := new(T)
+	m := reflect.MakeMapWithSize(reflect.TypeOf(tp).Elem(), len(cols))
+	for i, col := range cols {
+		val := vals[i]
+		// 部分驱动以[]byte返回文本
+		if b, ok := val.([]byte); ok {
+			val = string(b)
+		}
+		var v reflect.Value
+		if val == nil {
+			v = reflect.Zero(m.Type().Elem())
+		} else {
+			v = reflect.ValueOf(val)
+		}
+		m.SetMapIndex(reflect.ValueOf(col), v)
+	}
+	reflect.ValueOf(tp).Elem().Set(m)
+	return tp, nil
+}
@@ -0,0 +1,90 @@
+package simple_orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type UserDTO struct {
+	FirstName string
+	Total     int64
+}
+
+func TestScan(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// 基本类型
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+	cnt, err := NewRawQuery[int64](db, "SELECT COUNT(*) FROM `test_model`;").Get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), *cnt)
+
+	// 实现了sql.Scanner的结构体按基本类型处理
+	mock.ExpectQuery("SELECT `first_name`").WillReturnRows(
+		sqlmock.NewRows([]string{"first_name"}).AddRow("Deng").AddRow(nil))
+	names, err := NewRawQuery[sql.NullString](db, "SELECT `first_name` FROM `test_model`;").GetMul(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []*sql.NullString{{String: "Deng", Valid: true}, {}}, names)
+
+	// 基本类型只能读取单列
+	mock.ExpectQuery("SELECT \\*").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow(1, 18))
+	_, err = NewRawQuery[int64](db, "SELECT * FROM `test_model`;").Get(ctx)
+	assert.Equal(t, &QueryError{
+		SQL: "SELECT * FROM `test_model`;",
+		Err: errors.New("[scan] scalar result requires exactly one column"),
+	}, err)
+
+	// map
+	mock.ExpectQuery("SELECT \\*").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "first_name"}).AddRow(int64(1), []byte("Deng")))
+	rows, err := NewRawQuery[map[string]any](db, "SELECT * FROM `test_model`;").GetMul(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []*map[string]any{{"id": int64(1), "first_name": "Deng"}}, rows)
+
+	// 未注册的结构体按数据库列名匹配字段
+	mock.ExpectQuery("SELECT `first_name`").WillReturnRows(
+		sqlmock.NewRows([]string{"first_name", "total"}).AddRow("Deng", 2))
+	dto, err := NewRawQuery[UserDTO](db,
+		"SELECT `first_name`,COUNT(*) AS `total` FROM `test_model` GROUP BY `first_name`;").Get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, &UserDTO{FirstName: "Deng", Total: 2}, dto)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPluck(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("SELECT `id` FROM `test_model` WHERE `age` > ?;").
+		WithArgs(18).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	ids, err := Pluck[int64](context.Background(),
+		NewSelector[model.TestModel](db).Where(NewColumn("Age").GT(18)), "Id")
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	_, err = Pluck[int64](context.Background(), NewSelector[model.TestModel](db), "Invalid")
+	assert.Equal(t, errors.New("illegal field"), err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
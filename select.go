@@ -3,13 +3,14 @@ package simple_orm
 import (
 	"context"
 	"errors"
+	"reflect"
 )
 
 // Selector 用于构造 SELECT 语句
 type Selector[T any] struct {
 	Builder         // builder是Insert & Select公共部分
 	core            // core中是元数据信息
-	session session // session是db或tx
+	session Session // session是db或tx
 	table   string
 	where   []*Predicate
 	groupBy []*Column
@@ -17,9 +18,18 @@ type Selector[T any] struct {
 	orderBy []*OrderBy
 	limit   int
 	offset  int
+
+	unscoped bool // 不过滤软删除的数据
+	lockMode LockMode
+	lockWait LockWait
+	count    bool     // 构造COUNT(*)查询，见Page
+	columns  []string // 查询的列，为空时查询所有列，见Pluck
 }
 
-func NewSelector[T any](session session) *Selector[T] {
+// ErrLockOutsideTx 行锁在语句结束后立即释放，因此只能在事务中使用
+var ErrLockOutsideTx = errors.New("[lock] row lock requires a transaction")
+
+func NewSelector[T any](session Session) *Selector[T] {
 	return &Selector[T]{
 		core:    session.getCore(),
 		session: session,
@@ -62,6 +72,43 @@ func (s *Selector[T]) Offset(offset int) *Selector[T] {
 	return s
 }
 
+// Unscoped 查询包括软删除的数据
+func (s *Selector[T]) Unscoped() *Selector[T] {
+	s.unscoped = true
+	return s
+}
+
+// ForUpdate 对查询到的行加排他锁，只能在事务中使用
+func (s *Selector[T]) ForUpdate() *Selector[T] {
+	s.lockMode = LockModeUpdate
+	return s
+}
+
+// ForShare 对查询到的行加共享锁，只能在事务中使用
+func (s *Selector[T]) ForShare() *Selector[T] {
+	s.lockMode = LockModeShare
+	return s
+}
+
+// NoWait 行已被锁定时立即返回错误，需要与ForUpdate或ForShare一起使用
+func (s *Selector[T]) NoWait() *Selector[T] {
+	s.lockWait = LockWaitNoWait
+	return s
+}
+
+// SkipLocked 跳过已被锁定的行，适合多个消费者抢占任务，需要与ForUpdate或ForShare一起使用
+func (s *Selector[T]) SkipLocked() *Selector[T] {
+	s.lockWait = LockWaitSkipLocked
+	return s
+}
+
+// Use 添加仅对本次查询生效的中间件，在DB级别的中间件之后执行
+func (s *Selector[T]) Use(middleWares ...MiddleWare) *Selector[T] {
+	// 限制容量，避免append时修改DB共享的底层数组
+	s.middleWares = append(s.middleWares[:len(s.middleWares):len(s.middleWares)], middleWares...)
+	return s
+}
+
 func (s *Selector[T]) Build() (*Query, error) {
 	var (
 		t   T
@@ -71,7 +118,36 @@ func (s *Selector[T]) Build() (*Query, error) {
 	if err != nil {
 		return nil, err
 	}
-	s.sb.WriteString("SELECT * FROM ")
+	// select
+	switch {
+	case s.count && len(s.groupBy) > 0:
+		// 分组时统计组数
+		s.sb.WriteString("SELECT COUNT(*) FROM (SELECT ")
+		for i, v := range s.groupBy {
+			if err = s.buildExpression(v); err != nil {
+				return nil, err
+			}
+			if i != len(s.groupBy)-1 {
+				s.sb.WriteString(",")
+			}
+		}
+		s.sb.WriteString(" FROM ")
+	case s.count:
+		s.sb.WriteString("SELECT COUNT(*) FROM ")
+	case len(s.columns) > 0:
+		s.sb.WriteString("SELECT ")
+		for i, c := range s.columns {
+			if err = s.buildExpression(NewColumn(c)); err != nil {
+				return nil, err
+			}
+			if i != len(s.columns)-1 {
+				s.sb.WriteString(",")
+			}
+		}
+		s.sb.WriteString(" FROM ")
+	default:
+		s.sb.WriteString("SELECT * FROM ")
+	}
 	// table aggregateFunction
 	if s.table == "" {
 		s.sb.WriteByte('`')
@@ -82,16 +158,13 @@ func (s *Selector[T]) Build() (*Query, error) {
 	}
 
 	// where
-	if len(s.where) > 0 {
-		s.sb.WriteString(" WHERE ")
-		p := s.where[0]
-		for i := 1; i < len(s.where); i++ {
-			p = p.And(s.where[i])
-		}
-		err = s.buildExpression(p)
-		if err != nil {
-			return nil, err
-		}
+	where, err := s.tenantWhere(s.tenant, scopedWhere(s.tableModels, s.unscoped, s.where))
+	if err != nil {
+		return nil, err
+	}
+	err = s.buildWhere(where)
+	if err != nil {
+		return nil, err
 	}
 
 	// group by
@@ -139,13 +212,26 @@ func (s *Selector[T]) Build() (*Query, error) {
 	// limit
 	if s.limit != 0 {
 		s.sb.WriteString(" LIMIT ?")
-		s.args = append(s.args, s.limit)
+		s.addArg("", s.limit)
 	}
 
 	// offset
 	if s.offset != 0 {
 		s.sb.WriteString(" OFFSET ?")
-		s.args = append(s.args, s.offset)
+		s.addArg("", s.offset)
+	}
+
+	// lock
+	if s.lockMode != "" {
+		err = s.dialect.Lock(&s.Builder, s.lockMode, s.lockWait)
+		if err != nil {
+			return nil, err
+		}
+	} else if s.lockWait != "" {
+		return nil, errors.New("[lock] NOWAIT or SKIP LOCKED requires FOR UPDATE or FOR SHARE")
+	}
+	if s.count && len(s.groupBy) > 0 {
+		s.sb.WriteString(") AS `t`")
 	}
 	s.sb.WriteString(";")
 	return &Query{
@@ -154,68 +240,89 @@ func (s *Selector[T]) Build() (*Query, error) {
 	}, nil
 }
 
-// 递归解析表达式
-// (`Age` > 13) AND (`Age` < 24)
-func (s *Selector[T]) buildExpression(e Expression) error {
-	switch expr := e.(type) {
-	case *Aggregate:
-		field, ok := s.tableModels.Col2Field[expr.name]
-		if !ok {
-			return errors.New("illegal field")
-		}
-		s.sb.WriteString(string(expr.aggregateFunction))
-		s.sb.WriteString("(`")
-		s.sb.WriteString(field.ColumnName)
-		s.sb.WriteString("`)")
-	case *Column: // 列， eg：`Age`
-		if _, ok := s.tableModels.Col2Field[expr.name]; !ok {
-			return errors.New("illegal field")
-		}
-		s.sb.WriteByte('`')
-		s.sb.WriteString(s.tableModels.Col2Field[expr.name].ColumnName)
-		s.sb.WriteByte('`')
-	case *Value: // 值，eg： 13
-		s.sb.WriteByte('?')
-		s.args = append(s.args, expr.val)
-	case *Predicate: // 表达式
-		// 左侧表达式
-		_, lp := expr.left.(*Predicate)
-		if lp {
-			s.sb.WriteByte('(')
-		}
-		if err := s.buildExpression(expr.left); err != nil {
-			return err
-		}
-		if lp {
-			s.sb.WriteByte(')')
-		}
-		// 链接符
-		s.sb.WriteByte(' ')
-		s.sb.WriteString(string(expr.op))
-		s.sb.WriteByte(' ')
-		// 右侧表达式
-		_, rp := expr.right.(*Predicate)
-		if rp {
-			s.sb.WriteByte('(')
-		}
-		if err := s.buildExpression(expr.right); err != nil {
-			return err
-		}
-		if rp {
-			s.sb.WriteByte(')')
-		}
+// whereColumns WHERE中引用的列，没有WHERE时为nil
+func (s *Selector[T]) whereColumns() []string {
+	if len(s.where) == 0 {
+		return nil
+	}
+	return columnsOf(s.where...)
+}
+
+func (s *Selector[T]) limitValue() int {
+	return s.limit
+}
+
+// checkLock 加锁的查询必须在事务中执行
+func (s *Selector[T]) checkLock() error {
+	if s.lockMode == "" {
+		return nil
+	}
+	if _, ok := s.session.(*TX); !ok {
+		return ErrLockOutsideTx
 	}
 	return nil
 }
 
 func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
-	return get[T](ctx, s.core, s.session, &QueryContext{
-		Builder: s,
-	})
+	if err := s.checkLock(); err != nil {
+		return nil, err
+	}
+	return get[T](ctx, s.core, s.session, QueryTypeSelect, s)
 }
 
 func (s *Selector[T]) GetMul(ctx context.Context) ([]*T, error) {
-	return getMul[T](ctx, s.core, s.session, &QueryContext{
-		Builder: s,
-	})
+	if err := s.checkLock(); err != nil {
+		return nil, err
+	}
+	return getMul[T](ctx, s.core, s.session, QueryTypeSelect, s)
+}
+
+// Iter 流式读取查询结果，适用于大结果集，返回的迭代器必须Close
+func (s *Selector[T]) Iter(ctx context.Context) (*Iterator[T], error) {
+	if err := s.checkLock(); err != nil {
+		return nil, err
+	}
+	return iter[T](ctx, s.core, s.session, QueryTypeSelect, s)
+}
+
+// Each 逐行调用fn，fn返回错误时停止迭代并返回该错误
+func (s *Selector[T]) Each(ctx context.Context, fn func(*T) error) error {
+	it, err := s.Iter(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = it.Close() }()
+	for it.Next() {
+		if err = fn(it.Value()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// Pluck 查询单列，返回该列的值，eg：Pluck[int64](ctx, NewSelector[User](db).Where(...), "Id")
+func Pluck[V any, T any](ctx context.Context, s *Selector[T], column string) ([]V, error) {
+	if err := s.checkLock(); err != nil {
+		return nil, err
+	}
+	s.columns = []string{column}
+	qc, err := newQueryContext[T](ctx, s.core, s.session, QueryTypeSelect, s)
+	if err != nil {
+		return nil, err
+	}
+	qc.Multi = true
+	qc.ResultType = reflect.TypeOf([]*V(nil))
+	var handler HandleFunc = func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return getMulHandler[V](ctx, s.core, s.session, qc)
+	}
+	queryResult := chain(handler, s.middleWares)(ctx, qc)
+	if queryResult.Err != nil {
+		return nil, queryResult.Err
+	}
+	items := queryResult.Result.([]*V)
+	res := make([]V, 0, len(items))
+	for _, item := range items {
+		res = append(res, *item)
+	}
+	return res, nil
 }
@@ -334,9 +334,12 @@ func TestSelector_Get(t *testing.T) {
 		wantVal  *model.TestModel
 	}{
 		{
-			name:    "too many column",
-			wantErr: errors.New("the number of values in dest must be the same as the number of columns in Row"),
-			query:   "SELECT .*",
+			name: "too many column",
+			wantErr: &QueryError{
+				SQL: "SELECT * FROM `test_model`;",
+				Err: errors.New("the number of values in dest must be the same as the number of columns in Row"),
+			},
+			query: "SELECT .*",
 			mockRows: func() *sqlmock.Rows {
 				res := sqlmock.NewRows([]string{"id", "first_name", "age", "last_name", "extra_column"})
 				res.AddRow([]byte("1"), []byte("Da"), []byte("18"), []byte("Ming"), []byte("nothing"))
@@ -13,6 +13,8 @@ type ShardingBuilder struct {
 	stringBuffer *bytebufferpool.ByteBuffer
 	tableModels  *model.TableModel
 	args         []any
+	tenantVal    any  // 绑定的租户，见ShardingSelector.Tenant
+	tenantBound  bool // 是否已绑定租户
 }
 
 func (s *ShardingBuilder) FindDataSource(where ...*Predicate) ([]*sharding.DataSource, error) {
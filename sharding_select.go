@@ -1,6 +1,7 @@
 package simple_orm
 
 import (
+	"context"
 	"errors"
 	"github.com/simple_orm/sharding"
 	"github.com/valyala/bytebufferpool"
@@ -9,7 +10,7 @@ import (
 type ShardingSelector[T any] struct {
 	ShardingBuilder         // shardingBuilder是SQL的公共部分
 	core                    // core中是元数据信息
-	session         session // session是db或tx
+	session         Session // session是db或tx
 	table           string
 	where           []*Predicate
 	groupBy         []*Column
@@ -17,9 +18,12 @@ type ShardingSelector[T any] struct {
 	orderBy         []*OrderBy
 	limit           int
 	offset          int
+
+	unscoped  bool       // 不过滤软删除的数据
+	seekWhere *Predicate // 游标分页的定位条件，不参与分片路由
 }
 
-func NewShardingSelector[T any](session session) *ShardingSelector[T] {
+func NewShardingSelector[T any](session Session) *ShardingSelector[T] {
 	return &ShardingSelector[T]{
 		core:    session.getCore(),
 		session: session,
@@ -56,22 +60,28 @@ func (s *ShardingSelector[T]) Build() ([]*Query, error) {
 		}
 		queries = append(queries, query)
 		s.stringBuffer.Reset()
+		// 每个分片的参数独立
+		s.args = nil
 	}
 	return queries, nil
 }
 
 func (s *ShardingSelector[T]) buildQuery(dataSource *sharding.DataSource) (*Query, error) {
-	var (
-		err error
-	)
 	s.stringBuffer.WriteString("SELECT * FROM ")
 	s.stringBuffer.WriteString(dataSource.DB + "." + dataSource.Table)
 	// where
-	if len(s.where) > 0 {
+	where, err := s.tenantWhere(s.tenant, scopedWhere(s.tableModels, s.unscoped, s.where))
+	if err != nil {
+		return nil, err
+	}
+	if s.seekWhere != nil {
+		where = append(where[:len(where):len(where)], s.seekWhere)
+	}
+	if len(where) > 0 {
 		s.stringBuffer.WriteString(" WHERE ")
-		p := s.where[0]
-		for i := 1; i < len(s.where); i++ {
-			p = p.And(s.where[i])
+		p := where[0]
+		for i := 1; i < len(where); i++ {
+			p = p.And(where[i])
 		}
 		err = s.buildExpression(p)
 		if err != nil {
@@ -157,6 +167,17 @@ func (s *ShardingSelector[T]) buildExpression(e Expression) error {
 		s.stringBuffer.WriteByte('`')
 		s.stringBuffer.WriteString(s.tableModels.Col2Field[expr.name].ColumnName)
 		s.stringBuffer.WriteByte('`')
+	case *rowValue: // 行值，eg：(`a`,`b`)
+		s.stringBuffer.WriteByte('(')
+		for i, e := range expr.exprs {
+			if i > 0 {
+				s.stringBuffer.WriteByte(',')
+			}
+			if err := s.buildExpression(e); err != nil {
+				return err
+			}
+		}
+		s.stringBuffer.WriteByte(')')
 	case *Value: // 值，eg： 13
 		s.stringBuffer.WriteByte('?')
 		s.args = append(s.args, expr.val)
@@ -175,6 +196,10 @@ func (s *ShardingSelector[T]) buildExpression(e Expression) error {
 		// 链接符
 		s.stringBuffer.WriteByte(' ')
 		s.stringBuffer.WriteString(string(expr.op))
+		// IS NULL 等没有右侧表达式
+		if expr.right == nil {
+			break
+		}
 		s.stringBuffer.WriteByte(' ')
 		// 右侧表达式
 		_, rp := expr.right.(*Predicate)
@@ -226,3 +251,20 @@ func (s *ShardingSelector[T]) Offset(offset int) *ShardingSelector[T] {
 	s.offset = offset
 	return s
 }
+
+// Tenant 绑定ctx中的租户。分片查询由调用方执行，配置了租户隔离时必须在Build前调用，否则返回ErrNoTenant
+func (s *ShardingSelector[T]) Tenant(ctx context.Context) *ShardingSelector[T] {
+	if s.tenant == nil {
+		return s
+	}
+	if tenant, ok := s.tenant.extract(ctx); ok {
+		s.bindTenant(tenant)
+	}
+	return s
+}
+
+// Unscoped 查询包括软删除的数据
+func (s *ShardingSelector[T]) Unscoped() *ShardingSelector[T] {
+	s.unscoped = true
+	return s
+}
@@ -0,0 +1,55 @@
+package simple_orm
+
+import (
+	"context"
+	"database/sql"
+	"github.com/simple_orm/model"
+)
+
+// scopedWhere 软删除模型的查询追加 deleted_at IS NULL，unscoped时不追加
+func scopedWhere(tableModel *model.TableModel, unscoped bool, where []*Predicate) []*Predicate {
+	if tableModel.SoftDelete == nil || unscoped {
+		return where
+	}
+	res := make([]*Predicate, 0, len(where)+1)
+	res = append(res, where...)
+	return append(res, NewColumn(tableModel.SoftDelete.TypName).IsNull())
+}
+
+// Restorer 恢复软删除的数据，即将删除时间置为NULL
+type Restorer[T any] struct {
+	deleter *Delete[T]
+}
+
+func NewRestorer[T any](session Session) *Restorer[T] {
+	deleter := NewDeleter[T](session)
+	deleter.restore = true
+	return &Restorer[T]{
+		deleter: deleter,
+	}
+}
+
+// From 指定表名，如果是空字符串，那么将会使用默认表名
+func (r *Restorer[T]) From(tbl string) *Restorer[T] {
+	r.deleter.From(tbl)
+	return r
+}
+
+func (r *Restorer[T]) Where(where ...*Predicate) *Restorer[T] {
+	r.deleter.Where(where...)
+	return r
+}
+
+// Use 添加仅对本次查询生效的中间件，在DB级别的中间件之后执行
+func (r *Restorer[T]) Use(middleWares ...MiddleWare) *Restorer[T] {
+	r.deleter.Use(middleWares...)
+	return r
+}
+
+func (r *Restorer[T]) Build() (*Query, error) {
+	return r.deleter.Build()
+}
+
+func (r *Restorer[T]) Exec(ctx context.Context) (sql.Result, error) {
+	return r.deleter.Exec(ctx)
+}
@@ -0,0 +1,111 @@
+package simple_orm
+
+import (
+	"errors"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type SoftDeleteModel struct {
+	Id        int64
+	FirstName string
+	DeletedAt *time.Time
+}
+
+type SoftDeleteTagModel struct {
+	Id       int64
+	RemoveAt *int64 `orm:"soft_delete"`
+}
+
+func TestSoftDelete_Build(t *testing.T) {
+	db := memoryDB4UnitTest(t)
+	testCases := []struct {
+		name     string
+		q        QueryBuilder
+		wantSQL  string
+		wantArgs int
+		wantErr  error
+	}{
+		{
+			name:     "select scoped",
+			q:        NewSelector[SoftDeleteModel](db).Where(NewColumn("Id").EQ(1)),
+			wantSQL:  "SELECT * FROM `soft_delete_model` WHERE (`id` = ?) AND (`deleted_at` IS NULL);",
+			wantArgs: 1,
+		},
+		{
+			name:    "select without where",
+			q:       NewSelector[SoftDeleteModel](db),
+			wantSQL: "SELECT * FROM `soft_delete_model` WHERE `deleted_at` IS NULL;",
+		},
+		{
+			name:     "select unscoped",
+			q:        NewSelector[SoftDeleteModel](db).Unscoped().Where(NewColumn("Id").EQ(1)),
+			wantSQL:  "SELECT * FROM `soft_delete_model` WHERE `id` = ?;",
+			wantArgs: 1,
+		},
+		{
+			name:    "select without soft delete",
+			q:       NewSelector[model.TestModel](db),
+			wantSQL: "SELECT * FROM `test_model`;",
+		},
+		{
+			name:     "soft delete",
+			q:        NewDeleter[SoftDeleteModel](db).Where(NewColumn("Id").EQ(1)),
+			wantSQL:  "UPDATE `soft_delete_model` SET `deleted_at` = ? WHERE (`id` = ?) AND (`deleted_at` IS NULL);",
+			wantArgs: 2,
+		},
+		{
+			name:     "soft delete by tag",
+			q:        NewDeleter[SoftDeleteTagModel](db).Where(NewColumn("Id").EQ(1)),
+			wantSQL:  "UPDATE `soft_delete_tag_model` SET `remove_at` = ? WHERE (`id` = ?) AND (`remove_at` IS NULL);",
+			wantArgs: 2,
+		},
+		{
+			name:     "delete unscoped",
+			q:        NewDeleter[SoftDeleteModel](db).Unscoped().Where(NewColumn("Id").EQ(1)),
+			wantSQL:  "DELETE FROM `soft_delete_model` WHERE `id` = ?;",
+			wantArgs: 1,
+		},
+		{
+			name:     "restore",
+			q:        NewRestorer[SoftDeleteModel](db).Where(NewColumn("Id").EQ(1)),
+			wantSQL:  "UPDATE `soft_delete_model` SET `deleted_at` = NULL WHERE (`id` = ?) AND (`deleted_at` IS NOT NULL);",
+			wantArgs: 1,
+		},
+		{
+			name:    "restore without soft delete",
+			q:       NewRestorer[model.TestModel](db),
+			wantErr: errors.New("[restore] model has no soft delete field"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.q.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantSQL, q.SQL)
+			assert.Len(t, q.Args, tc.wantArgs)
+		})
+	}
+}
+
+func TestSoftDelete_Value(t *testing.T) {
+	q, err := NewDeleter[SoftDeleteTagModel](memoryDB4UnitTest(t)).Build()
+	assert.Nil(t, err)
+	// 整型指针字段写入unix秒，未删除时为NULL
+	assert.IsType(t, int64(0), q.Args[0])
+
+	q, err = NewDeleter[SoftDeleteModel](memoryDB4UnitTest(t)).Build()
+	assert.Nil(t, err)
+	assert.IsType(t, time.Time{}, q.Args[0])
+
+	// 新插入的行删除时间为NULL，才能被查询到
+	q, err = NewInserter[SoftDeleteTagModel](memoryDB4UnitTest(t)).Values(&SoftDeleteTagModel{Id: 1}).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `soft_delete_tag_model`(`id`,`remove_at`) VALUES(?,?);", q.SQL)
+	assert.Nil(t, q.Args[1])
+}
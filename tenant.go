@@ -0,0 +1,110 @@
+package simple_orm
+
+import (
+	"context"
+	"errors"
+	"github.com/simple_orm/model"
+	"reflect"
+)
+
+// ErrNoTenant 配置了租户隔离但ctx中没有租户，拒绝执行
+var ErrNoTenant = errors.New("[tenant] no tenant in context")
+
+// ErrTenantAssign 冲突更新时修改租户字段，拒绝执行
+var ErrTenantAssign = errors.New("[tenant] upsert cannot update tenant column")
+
+// TenantFunc 从ctx中提取租户，ok为false表示没有租户
+type TenantFunc func(ctx context.Context) (tenant any, ok bool)
+
+type tenantRule struct {
+	column  string // 租户字段名
+	extract TenantFunc
+}
+
+// bindTenant 执行前绑定ctx中的租户，Build时使用
+func (b *Builder) bindTenant(tenant any) {
+	b.tenantVal = tenant
+	b.tenantBound = true
+}
+
+// tenantWhere 追加租户条件，模型没有租户字段时视为全局表不做处理
+func (b *Builder) tenantWhere(rule *tenantRule, where []*Predicate) ([]*Predicate, error) {
+	return appendTenant(rule, b.tableModels, b.tenantBound, b.tenantVal, where)
+}
+
+// bindTenant 分片查询不经过中间件链，由调用方通过ShardingSelector.Tenant绑定
+func (s *ShardingBuilder) bindTenant(tenant any) {
+	s.tenantVal = tenant
+	s.tenantBound = true
+}
+
+func (s *ShardingBuilder) tenantWhere(rule *tenantRule, where []*Predicate) ([]*Predicate, error) {
+	return appendTenant(rule, s.tableModels, s.tenantBound, s.tenantVal, where)
+}
+
+func appendTenant(rule *tenantRule, tableModel *model.TableModel, bound bool, tenant any, where []*Predicate) ([]*Predicate, error) {
+	if rule == nil {
+		return where, nil
+	}
+	if _, ok := tableModel.Col2Field[rule.column]; !ok {
+		return where, nil
+	}
+	if !bound {
+		return nil, ErrNoTenant
+	}
+	return append(where[:len(where):len(where)], NewColumn(rule.column).EQ(tenant)), nil
+}
+
+// tenantUpsert 冲突更新不能修改租户字段，且只能更新当前租户的行
+func (b *Builder) tenantUpsert(rule *tenantRule, upsert *UpsertKey) (*UpsertKey, error) {
+	if rule == nil {
+		return upsert, nil
+	}
+	if _, ok := b.tableModels.Col2Field[rule.column]; !ok {
+		return upsert, nil
+	}
+	for _, assign := range upsert.assigns {
+		switch e := assign.(type) {
+		case *Column:
+			if e.name == rule.column {
+				return nil, ErrTenantAssign
+			}
+		case *Assignment:
+			if e.ColumnName == rule.column {
+				return nil, ErrTenantAssign
+			}
+		}
+	}
+	where, err := b.tenantWhere(rule, upsert.where)
+	if err != nil {
+		return nil, err
+	}
+	return &UpsertKey{
+		assigns: upsert.assigns,
+		where:   where,
+	}, nil
+}
+
+// fillTenant 插入前将租户写入实体，覆盖实体中原有的值
+func (b *Builder) fillTenant(rule *tenantRule, entity any) error {
+	if rule == nil {
+		return nil
+	}
+	if _, ok := b.tableModels.Col2Field[rule.column]; !ok {
+		return nil
+	}
+	if !b.tenantBound {
+		return ErrNoTenant
+	}
+	val := reflect.ValueOf(entity)
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	fd := val.FieldByName(rule.column)
+	tenant := reflect.ValueOf(b.tenantVal)
+	if !fd.CanSet() || !tenant.IsValid() || !tenant.Type().ConvertibleTo(fd.Type()) {
+		return errors.New("[tenant] tenant type mismatch")
+	}
+	fd.Set(tenant.Convert(fd.Type()))
+	return nil
+}
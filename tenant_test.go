@@ -0,0 +1,179 @@
+package simple_orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm/model"
+	"github.com/simple_orm/sharding"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type TenantModel struct {
+	Id        int64
+	TenantId  int64
+	FirstName string
+}
+
+type tenantKey struct{}
+
+func tenantFromCtx(ctx context.Context) (any, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(int64)
+	return tenant, ok
+}
+
+func TestTenant(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB, DBWithTenant("TenantId", tenantFromCtx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+
+	// 查询追加租户条件
+	mock.ExpectQuery("SELECT \\* FROM `tenant_model` WHERE \\(`id` = \\?\\) AND \\(`tenant_id` = \\?\\);").
+		WithArgs(1, int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "TenantId", "FirstName"}).AddRow(1, 7, "Deng"))
+	_, err = NewSelector[TenantModel](db).Where(NewColumn("Id").EQ(1)).Get(ctx)
+	assert.Nil(t, err)
+
+	// 插入写入租户，指定的列中缺少租户字段时补充
+	val := &TenantModel{Id: 1, TenantId: 8, FirstName: "Deng"}
+	mock.ExpectExec("INSERT INTO `tenant_model`\\(`id`,`first_name`,`tenant_id`\\)").
+		WithArgs(int64(1), "Deng", int64(7)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err = NewInserter[TenantModel](db).Columns("Id", "FirstName").Values(val).Exec(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), val.TenantId)
+
+	// 更新不修改租户
+	mock.ExpectExec("UPDATE `tenant_model` SET `id`=\\?,`first_name`=\\? WHERE \\(`id` = \\?\\) AND \\(`tenant_id` = \\?\\);").
+		WithArgs(int64(1), "Deng", 1, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = NewUpdater[TenantModel](db).Update(val).Where(NewColumn("Id").EQ(1)).Exec(ctx)
+	assert.Nil(t, err)
+
+	// 事务中同样生效
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM `tenant_model` WHERE `tenant_id` = \\?;").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err = db.doTx(ctx, func(ctx context.Context, tx *TX) error {
+		_, err := NewDeleter[TenantModel](tx).Exec(ctx)
+		return err
+	}, &sql.TxOptions{})
+	assert.Nil(t, err)
+
+	// 没有租户时拒绝执行
+	_, err = NewSelector[TenantModel](db).Get(context.Background())
+	assert.Equal(t, ErrNoTenant, err)
+	_, err = NewInserter[TenantModel](db).Values(&TenantModel{}).Exec(context.Background())
+	assert.Equal(t, ErrNoTenant, err)
+	_, err = NewDeleter[TenantModel](db).Exec(context.Background())
+	assert.Equal(t, ErrNoTenant, err)
+	_, err = NewUpdater[TenantModel](db).Update(val).Where(NewColumn("Id").EQ(1)).Exec(context.Background())
+	assert.Equal(t, ErrNoTenant, err)
+
+	// 没有租户字段的模型视为全局表
+	mock.ExpectQuery("SELECT \\* FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow(1))
+	_, err = NewSelector[model.TestModel](db).Get(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTenant_ShardingSelector(t *testing.T) {
+	db, err := Open("sqlite3", "file:test.db?cache=shared&mode=memory",
+		DBWithTenant("TenantId", tenantFromCtx),
+		DBWithShardingAlgorithm(sharding.NewHashAlgorithm("Id", map[string]struct{}{}, &sharding.Pattern{
+			Base: 2, DefaultName: "order_db", IsSharding: true,
+		}, &sharding.Pattern{
+			DefaultName: "order_tab",
+		})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+
+	queries, err := NewShardingSelector[TenantModel](db).Tenant(ctx).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Query{
+		{SQL: "SELECT * FROM order_db0.order_tab WHERE `tenant_id` = ?;", Args: []any{int64(7)}},
+		{SQL: "SELECT * FROM order_db1.order_tab WHERE `tenant_id` = ?;", Args: []any{int64(7)}},
+	}, queries)
+
+	// 游标分页同样追加租户条件
+	next, err := NextCursor[TenantModel](db, &TenantModel{Id: 10}, Asc("Id"))
+	assert.Nil(t, err)
+	queries, err = NewShardingSelector[TenantModel](db).Tenant(ctx).OrderBy(Asc("Id")).Paginate(next, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM order_db0.order_tab WHERE (`tenant_id` = ?) AND (`id` > ?) ORDER BY `id` ASC LIMIT ?;", queries[0].SQL)
+	assert.Equal(t, []any{int64(7), int64(10), 21}, queries[0].Args)
+
+	// 未绑定租户时拒绝构造
+	_, err = NewShardingSelector[TenantModel](db).Build()
+	assert.Equal(t, ErrNoTenant, err)
+	_, err = NewShardingSelector[TenantModel](db).Tenant(context.Background()).OrderBy(Asc("Id")).Paginate("", 20)
+	assert.Equal(t, ErrNoTenant, err)
+}
+
+func TestTenant_Upsert(t *testing.T) {
+	db, err := Open("sqlite3", "file:tenant_upsert.db?cache=shared&mode=memory",
+		DBWithDialect(&SQLiteDialect{}), DBWithTenant("TenantId", tenantFromCtx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.store.Exec("CREATE TABLE `tenant_model`(`id` INTEGER PRIMARY KEY, `tenant_id` INTEGER, `first_name` TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _, _ = db.store.Exec("DROP TABLE `tenant_model`") }()
+	_, err = db.store.Exec("INSERT INTO `tenant_model` VALUES(1, 8, 'Tom')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+
+	// 冲突时只更新当前租户的行
+	i := NewInserter[TenantModel](db).Values(&TenantModel{Id: 1, FirstName: "Deng"}).
+		OnDuplicateKey().Update(NewColumn("FirstName"))
+	i.bindTenant(int64(7))
+	query, err := i.Build()
+	assert.Nil(t, err)
+	assert.Equal(t, &Query{
+		SQL: "INSERT INTO `tenant_model`(`id`,`tenant_id`,`first_name`) VALUES(?,?,?) " +
+			"ON CONFLICT DO UPDATE SET `first_name`=excluded.`first_name` WHERE `tenant_id` = ?;",
+		Args: []any{int64(1), int64(7), "Deng", int64(7)},
+	}, query)
+	_, err = NewInserter[TenantModel](db).Values(&TenantModel{Id: 1, FirstName: "Deng"}).
+		OnDuplicateKey().Update(NewColumn("FirstName")).Exec(ctx)
+	assert.Nil(t, err)
+	var name string
+	assert.Nil(t, db.store.QueryRow("SELECT `first_name` FROM `tenant_model` WHERE `id` = 1").Scan(&name))
+	assert.Equal(t, "Tom", name)
+
+	// 不能修改租户字段
+	_, err = NewInserter[TenantModel](db).Values(&TenantModel{Id: 1}).
+		OnDuplicateKey().Update(NewColumn("FirstName"), NewColumn("TenantId")).Exec(ctx)
+	assert.Equal(t, ErrTenantAssign, err)
+	_, err = NewInserter[TenantModel](db).Values(&TenantModel{Id: 1}).
+		OnDuplicateKey().Update(Assign("TenantId", 8)).Exec(ctx)
+	assert.Equal(t, ErrTenantAssign, err)
+
+	// MySQL无法限定更新的行，拒绝租户表的冲突更新
+	mysqlDB, err := Open("sqlite3", "file:tenant_upsert.db?cache=shared&mode=memory",
+		DBWithTenant("TenantId", tenantFromCtx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewInserter[TenantModel](mysqlDB).Values(&TenantModel{Id: 1}).
+		OnDuplicateKey().Update(NewColumn("FirstName")).Exec(ctx)
+	assert.Equal(t, errors.New("[upsert] mysql does not support conditional upsert"), err)
+}
@@ -3,13 +3,15 @@ package simple_orm
 import (
 	"context"
 	"database/sql"
+	"github.com/simple_orm/master_slave"
 	"github.com/simple_orm/model"
 	"github.com/simple_orm/sharding"
 	"github.com/simple_orm/valuer"
+	"time"
 )
 
-// db & tx 均对这个接口做了实现
-type session interface {
+// Session db & tx 均对这个接口做了实现，方法均不导出，外部只能使用DB与TX
+type Session interface {
 	getCore() core
 	queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 	execContext(ctx context.Context, query string, args ...any) (sql.Result, error)
@@ -17,8 +19,11 @@ type session interface {
 
 type TX struct {
 	core
-	tx *sql.Tx
-	db *DB
+	tx  *sql.Tx
+	db  *DB
+	ctx context.Context // 开启事务时的ctx，提交后用于刷新写后粘滞窗口
+
+	afterCommit []func() // 提交成功后执行的回调
 }
 
 type core struct {
@@ -27,6 +32,8 @@ type core struct {
 	dialect     Dialect        // 方言
 	middleWares []MiddleWare   // 切片的中间件
 	algorithm   sharding.Algorithm
+	clock       func() time.Time // 时间源，用于自动写入的时间字段
+	tenant      *tenantRule      // 租户隔离，没有配置时为nil
 }
 
 func (t *TX) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
@@ -42,7 +49,22 @@ func (t *TX) getCore() core {
 }
 
 func (t *TX) Commit() error {
-	return t.tx.Commit()
+	err := t.tx.Commit()
+	if err != nil {
+		return err
+	}
+	if t.ctx != nil {
+		master_slave.MarkWrite(t.ctx)
+	}
+	for _, fn := range t.afterCommit {
+		fn()
+	}
+	return nil
+}
+
+// AfterCommit 注册事务提交成功后执行的回调，回滚时不会执行，供中间件在提交后刷新缓存等
+func (t *TX) AfterCommit(fn func()) {
+	t.afterCommit = append(t.afterCommit, fn)
 }
 
 func (t *TX) Rollback() error {
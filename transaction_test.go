@@ -54,3 +54,35 @@ func TestTx_Rollback(t *testing.T) {
 	err = tx.Rollback()
 	assert.Nil(t, err)
 }
+
+func TestTx_AfterCommit(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 回滚不执行回调
+	called := 0
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	tx, err := db.beginTx(context.Background(), &sql.TxOptions{})
+	assert.Nil(t, err)
+	tx.AfterCommit(func() { called++ })
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, 0, called)
+
+	// 提交后执行回调
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	tx, err = db.beginTx(context.Background(), &sql.TxOptions{})
+	assert.Nil(t, err)
+	tx.AfterCommit(func() { called++ })
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, 1, called)
+}
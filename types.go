@@ -23,6 +23,15 @@ type QueryBuilder interface {
 	Build() (*Query, error)
 }
 
+// 语句类型，见QueryContext.Type
+const (
+	QueryTypeSelect = "SELECT"
+	QueryTypeInsert = "INSERT"
+	QueryTypeDelete = "DELETE"
+	QueryTypeUpdate = "UPDATE"
+	QueryTypeRaw    = "RAW"
+)
+
 type AggregateFunction string
 
 const (
@@ -36,3 +45,19 @@ const (
 	ASCOrder  = "ASC"
 	DESCOrder = "DESC"
 )
+
+// LockMode 行锁类型，见Selector.ForUpdate与Selector.ForShare
+type LockMode string
+
+const (
+	LockModeUpdate LockMode = "FOR UPDATE"
+	LockModeShare  LockMode = "FOR SHARE"
+)
+
+// LockWait 行已被锁定时的处理方式，默认等待
+type LockWait string
+
+const (
+	LockWaitNoWait     LockWait = "NOWAIT"
+	LockWaitSkipLocked LockWait = "SKIP LOCKED"
+)
@@ -0,0 +1,210 @@
+package simple_orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/simple_orm/master_slave"
+	"reflect"
+)
+
+// ErrStaleObject 乐观锁冲突：实体的版本号与数据库不一致，数据已被其他请求修改或删除
+var ErrStaleObject = errors.New("[update] stale object")
+
+// Updater 根据实体构造 UPDATE 语句
+type Updater[T any] struct {
+	Builder
+	core
+	session Session
+	table   string
+	val     *T
+	columns []string
+	where   []*Predicate
+
+	unscoped bool // 不过滤软删除的数据
+}
+
+func NewUpdater[T any](session Session) *Updater[T] {
+	return &Updater[T]{
+		core:    session.getCore(),
+		session: session,
+	}
+}
+
+// Update 指定要写入的实体
+func (u *Updater[T]) Update(val *T) *Updater[T] {
+	u.val = val
+	return u
+}
+
+// Columns 指定更新的列，默认更新除版本号外的所有列
+func (u *Updater[T]) Columns(columns ...string) *Updater[T] {
+	u.columns = columns
+	return u
+}
+
+// From 指定表名，如果是空字符串，那么将会使用默认表名
+func (u *Updater[T]) From(tbl string) *Updater[T] {
+	u.table = tbl
+	return u
+}
+
+func (u *Updater[T]) Where(where ...*Predicate) *Updater[T] {
+	u.where = where
+	return u
+}
+
+// Unscoped 软删除的数据也会被更新
+func (u *Updater[T]) Unscoped() *Updater[T] {
+	u.unscoped = true
+	return u
+}
+
+// Use 添加仅对本次查询生效的中间件，在DB级别的中间件之后执行
+func (u *Updater[T]) Use(middleWares ...MiddleWare) *Updater[T] {
+	// 限制容量，避免append时修改DB共享的底层数组
+	u.middleWares = append(u.middleWares[:len(u.middleWares):len(u.middleWares)], middleWares...)
+	return u
+}
+
+func (u *Updater[T]) Build() (*Query, error) {
+	if u.val == nil {
+		return nil, errors.New("[update] update nil value")
+	}
+	// 实体的值会写入所有满足条件的行，没有条件时整张表都会被覆盖
+	if len(u.where) == 0 {
+		return nil, errors.New("[update] where is required")
+	}
+	var (
+		t   T
+		err error
+	)
+	u.tableModels, err = u.r.Get(t)
+	if err != nil {
+		return nil, err
+	}
+	version := u.tableModels.Version
+	if updateTime := u.tableModels.UpdateTime; updateTime != nil {
+		err = setTime(u.val, updateTime, u.clock(), false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	u.sb.WriteString("UPDATE ")
+	if u.table == "" {
+		u.sb.WriteByte('`')
+		u.sb.WriteString(u.tableModels.TableName)
+		u.sb.WriteByte('`')
+	} else {
+		u.sb.WriteString(u.table)
+	}
+
+	// set
+	u.sb.WriteString(" SET ")
+	columns := u.columns
+	if len(columns) == 0 {
+		columns = u.tableModels.ColumnNames
+	}
+	columns = withAutoTimeColumns(columns, u.tableModels.UpdateTime)
+	internalVal := u.creator(u.val, u.tableModels)
+	cnt := 0
+	for _, column := range columns {
+		field, ok := u.tableModels.Col2Field[column]
+		if !ok {
+			return nil, errors.New("field not exists")
+		}
+		// 版本号由数据库自增，不使用实体中的值；租户不允许修改
+		if field.Version || (u.tenant != nil && column == u.tenant.column) {
+			continue
+		}
+		colVal, err := internalVal.GetValByColName(column)
+		if err != nil {
+			return nil, err
+		}
+		if cnt > 0 {
+			u.sb.WriteString(",")
+		}
+		u.sb.WriteString("`" + field.ColumnName + "`=?")
+		u.addArg(column, colVal)
+		cnt++
+	}
+	where := scopedWhere(u.tableModels, u.unscoped, u.where)
+	if version != nil {
+		if cnt > 0 {
+			u.sb.WriteString(",")
+		}
+		u.sb.WriteString("`" + version.ColumnName + "`=`" + version.ColumnName + "`+1")
+		// 当前版本号作为条件，版本不一致时不会更新任何行
+		current, err := internalVal.GetValByColName(version.TypName)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where[:len(where):len(where)], NewColumn(version.TypName).EQ(current))
+	} else if cnt == 0 {
+		return nil, errors.New("[update] no column to update")
+	}
+
+	// where
+	where, err = u.tenantWhere(u.tenant, where)
+	if err != nil {
+		return nil, err
+	}
+	err = u.buildWhere(where)
+	if err != nil {
+		return nil, err
+	}
+	u.sb.WriteString(";")
+	return &Query{
+		SQL:  u.sb.String(),
+		Args: u.args,
+	}, nil
+}
+
+// whereColumns WHERE中引用的列，没有WHERE时为nil，不包括自动追加的版本号与软删除条件
+func (u *Updater[T]) whereColumns() []string {
+	if len(u.where) == 0 {
+		return nil
+	}
+	return columnsOf(u.where...)
+}
+
+func (u *Updater[T]) execHandler(ctx context.Context, qc *QueryContext) *QueryResult {
+	result, err := u.session.execContext(ctx, qc.Query.SQL, qc.Query.Args...)
+	if err != nil {
+		return &QueryResult{
+			Err: wrapError(u.dialect, qc.Query.SQL, err),
+		}
+	}
+	return newExecResult(result)
+}
+
+// Exec 执行更新，有版本号时没有更新任何行返回ErrStaleObject，成功后实体的版本号加一
+func (u *Updater[T]) Exec(ctx context.Context) (sql.Result, error) {
+	qc, err := newQueryContext[T](ctx, u.core, u.session, QueryTypeUpdate, u)
+	if err != nil {
+		return nil, err
+	}
+	queryResult := chain(u.execHandler, u.middleWares)(ctx, qc)
+	if queryResult.Err != nil {
+		return nil, queryResult.Err
+	}
+	result := queryResult.Result.(sql.Result)
+	// 写入成功后，同一ctx中的读请求在粘滞窗口内走主库
+	master_slave.MarkWrite(ctx)
+	if version := u.tableModels.Version; version != nil {
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected == 0 {
+			return nil, ErrStaleObject
+		}
+		fd := reflect.ValueOf(u.val).Elem().FieldByName(version.TypName)
+		if fd.CanInt() {
+			fd.SetInt(fd.Int() + 1)
+		} else {
+			fd.SetUint(fd.Uint() + 1)
+		}
+	}
+	return result, nil
+}
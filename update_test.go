@@ -0,0 +1,146 @@
+package simple_orm
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/simple_orm/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type VersionModel struct {
+	Id        int64
+	FirstName string
+	Version   int64 `orm:"version"`
+}
+
+type VersionSoftDeleteModel struct {
+	Id        int64
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+	Version   int64 `orm:"version"`
+}
+
+func TestUpdater_Build(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	db, err := Open("sqlite3", "file:test.db?cache=shared&mode=memory",
+		DBWithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name      string
+		q         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "nil value",
+			q:       NewUpdater[model.TestModel](db),
+			wantErr: errors.New("[update] update nil value"),
+		},
+		{
+			name: "all columns",
+			q: NewUpdater[model.TestModel](db).Update(&model.TestModel{Id: 1, FirstName: "Deng", Age: 18}).
+				Where(NewColumn("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `id`=?,`first_name`=?,`age`=? WHERE `id` = ?;",
+				Args: []any{int64(1), "Deng", int8(18), 1},
+			},
+		},
+		{
+			name: "specify columns",
+			q: NewUpdater[model.TestModel](db).Update(&model.TestModel{Id: 1, FirstName: "Deng", Age: 18}).
+				Columns("FirstName").Where(NewColumn("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `first_name`=? WHERE `id` = ?;",
+				Args: []any{"Deng", 1},
+			},
+		},
+		{
+			name:    "without where",
+			q:       NewUpdater[model.TestModel](db).Update(&model.TestModel{Id: 1, FirstName: "Deng"}),
+			wantErr: errors.New("[update] where is required"),
+		},
+		{
+			name: "invalid column",
+			q: NewUpdater[model.TestModel](db).Update(&model.TestModel{}).
+				Columns("Invalid").Where(NewColumn("Id").EQ(1)),
+			wantErr: errors.New("field not exists"),
+		},
+		{
+			// 版本号自增并作为条件
+			name: "version",
+			q: NewUpdater[VersionModel](db).Update(&VersionModel{Id: 1, FirstName: "Deng", Version: 3}).
+				Where(NewColumn("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `version_model` SET `id`=?,`first_name`=?,`version`=`version`+1 WHERE (`id` = ?) AND (`version` = ?);",
+				Args: []any{int64(1), "Deng", 1, int64(3)},
+			},
+		},
+		{
+			// 自动更新时间与软删除条件
+			name: "update time and soft delete",
+			q: NewUpdater[VersionSoftDeleteModel](db).Update(&VersionSoftDeleteModel{Id: 1, Version: 3}).
+				Columns("Id", "UpdatedAt").Where(NewColumn("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL: "UPDATE `version_soft_delete_model` SET `id`=?,`updated_at`=?,`version`=`version`+1 " +
+					"WHERE ((`id` = ?) AND (`deleted_at` IS NULL)) AND (`version` = ?);",
+				Args: []any{int64(1), now, 1, int64(3)},
+			},
+		},
+		{
+			// 软删除同样递增版本号
+			name: "soft delete with version",
+			q:    NewDeleter[VersionSoftDeleteModel](db).Where(NewColumn("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL: "UPDATE `version_soft_delete_model` SET `deleted_at` = ?,`updated_at` = ?,`version` = `version` + 1 " +
+					"WHERE (`id` = ?) AND (`deleted_at` IS NULL);",
+				Args: []any{now, now, 1},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.q.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestUpdater_Version(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db, err := OpenDB(mockDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 更新成功，实体版本号加一
+	val := &VersionModel{Id: 1, FirstName: "Deng", Version: 3}
+	mock.ExpectExec("UPDATE `version_model`").
+		WithArgs(int64(1), "Deng", int64(1), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = NewUpdater[VersionModel](db).Update(val).Where(NewColumn("Id").EQ(1)).Exec(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), val.Version)
+
+	// 版本号已过期
+	stale := &VersionModel{Id: 1, FirstName: "Tom", Version: 3}
+	mock.ExpectExec("UPDATE `version_model`").
+		WithArgs(int64(1), "Tom", int64(1), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = NewUpdater[VersionModel](db).Update(stale).Where(NewColumn("Id").EQ(1)).Exec(context.Background())
+	assert.Equal(t, ErrStaleObject, err)
+	assert.Equal(t, int64(3), stale.Version)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -40,7 +40,7 @@ func (r ReflectValue) SetColumns(rows *sql.Rows) error {
 	colValues := make([]interface{}, len(cols))
 	colEleValues := make([]reflect.Value, len(cols))
 	for i, col := range cols {
-		field, ok := r.tableModel.Col2Field[col]
+		field, ok := r.tableModel.FieldByColumn(col)
 		if !ok {
 			return ColumnsNotExists
 		}
@@ -53,7 +53,7 @@ func (r ReflectValue) SetColumns(rows *sql.Rows) error {
 		return err
 	}
 	for i, col := range cols {
-		field, ok := r.tableModel.Col2Field[col]
+		field, ok := r.tableModel.FieldByColumn(col)
 		if !ok {
 			return ColumnsNotExists
 		}
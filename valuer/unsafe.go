@@ -30,7 +30,7 @@ func (u UnsafeValue) SetColumns(rows *sql.Rows) error {
 	}
 	colVal := make([]interface{}, len(columnsFromDB))
 	for i, column := range columnsFromDB {
-		field, ok := u.tableModel.Col2Field[column]
+		field, ok := u.tableModel.FieldByColumn(column)
 		if !ok {
 			return ColumnsNotExists
 		}